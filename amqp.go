@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	amqptransport "github.com/go-kit/kit/transport/amqp"
+	"github.com/streadway/amqp"
+)
+
+// Queues consumed by the AMQP transport.
+const (
+	amqpUppercaseQueue = "stringsvc.uppercase"
+	amqpCountQueue     = "stringsvc.count"
+)
+
+// consumeAMQP serves the Uppercase and Count endpoints on their AMQP queues,
+// declaring them if needed. Like the NATS transport, bodies are JSON in the
+// same shape as the HTTP transport. Replies are published to the delivery's
+// ReplyTo queue with its CorrelationId, and AMQP calls are rejected when API
+// keys are configured.
+func consumeAMQP(ch *amqp.Channel, uppercase, count endpoint.Endpoint, logger log.Logger) error {
+	options := []amqptransport.SubscriberOption{
+		amqptransport.SubscriberErrorEncoder(encodeAMQPError),
+		amqptransport.SubscriberErrorLogger(logger),
+	}
+
+	uppercaseSubscriber := amqptransport.NewSubscriber(
+		uppercase,
+		decodeAMQPUppercaseRequest,
+		amqptransport.EncodeJSONResponse,
+		options...,
+	)
+	countSubscriber := amqptransport.NewSubscriber(
+		count,
+		decodeAMQPCountRequest,
+		amqptransport.EncodeJSONResponse,
+		options...,
+	)
+
+	for queue, subscriber := range map[string]*amqptransport.Subscriber{
+		amqpUppercaseQueue: uppercaseSubscriber,
+		amqpCountQueue:     countSubscriber,
+	} {
+		if _, err := ch.QueueDeclare(queue, false, false, false, false, nil); err != nil {
+			return err
+		}
+		deliveries, err := ch.Consume(queue, "", true, false, false, false, nil)
+		if err != nil {
+			return err
+		}
+		handle := subscriber.ServeDelivery(ch)
+		go func() {
+			for d := range deliveries {
+				handle(&d)
+			}
+		}()
+	}
+	return nil
+}
+
+func decodeAMQPUppercaseRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var request uppercaseRequest
+	if err := json.Unmarshal(d.Body, &request); err != nil {
+		return nil, ErrBadRequest
+	}
+	return request, nil
+}
+
+func decodeAMQPCountRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var request countRequest
+	if err := json.Unmarshal(d.Body, &request); err != nil {
+		return nil, ErrBadRequest
+	}
+	return request, nil
+}
+
+// encodeAMQPError replies with the error in the same JSON shape as
+// encodeError.
+func encodeAMQPError(_ context.Context, err error, d *amqp.Delivery, ch amqptransport.Channel, pub *amqp.Publishing) {
+	pub.CorrelationId = d.CorrelationId
+	pub.Body, _ = json.Marshal(newErrorResponse(err))
+	ch.Publish("", d.ReplyTo, false, false, *pub)
+}
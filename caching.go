@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// cachingMiddleware memoizes the results of Uppercase and Count, which are
+// pure functions of their input. Only successful results for inputs of at
+// most maxInput bytes are cached, which bounds the cache's memory to about
+// size times maxInput. Every other method, and validate-only calls, go
+// straight to the embedded StringService.
+type cachingMiddleware struct {
+	StringService
+	cache    *lru.Cache
+	maxInput int
+	hits     metrics.Counter
+}
+
+// cacheKey identifies a result. lang is the caller's language for methods
+// whose result depends on it, and empty otherwise.
+type cacheKey struct {
+	method string
+	lang   string
+	s      string
+}
+
+// newCachingMiddleware returns a Middleware whose cache holds at most size
+// results, for inputs of up to maxInput bytes.
+func newCachingMiddleware(size, maxInput int, hits metrics.Counter) (Middleware, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return func(next StringService) StringService {
+		return cachingMiddleware{next, cache, maxInput, hits}
+	}, nil
+}
+
+func (mw cachingMiddleware) get(key cacheKey) (interface{}, bool) {
+	v, ok := mw.cache.Get(key)
+	if ok {
+		mw.hits.With("method", key.method).Add(1)
+	}
+	return v, ok
+}
+
+func (mw cachingMiddleware) Uppercase(ctx context.Context, s string) (string, error) {
+	if validateOnly(ctx) || len(s) > mw.maxInput {
+		return mw.StringService.Uppercase(ctx, s)
+	}
+	key := cacheKey{method: "uppercase", s: s}
+	if tag, ok := languageFromContext(ctx); ok {
+		key.lang = tag.String()
+	}
+	if v, ok := mw.get(key); ok {
+		return v.(string), nil
+	}
+	v, err := mw.StringService.Uppercase(ctx, s)
+	if err != nil {
+		return "", err
+	}
+	mw.cache.Add(key, v)
+	return v, nil
+}
+
+func (mw cachingMiddleware) Count(ctx context.Context, s string) (Counts, error) {
+	if validateOnly(ctx) || len(s) > mw.maxInput {
+		return mw.StringService.Count(ctx, s)
+	}
+	key := cacheKey{method: "count", s: s}
+	if v, ok := mw.get(key); ok {
+		return v.(Counts), nil
+	}
+	v, err := mw.StringService.Count(ctx, s)
+	if err != nil {
+		return Counts{}, err
+	}
+	mw.cache.Add(key, v)
+	return v, nil
+}
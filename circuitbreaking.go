@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/circuitbreaker"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/sony/gobreaker"
+)
+
+// ErrCircuitOpen is returned when a circuit breaker is rejecting calls.
+var ErrCircuitOpen = &ServiceError{Code: "circuit_open", Status: http.StatusServiceUnavailable, Message: "circuit breaker open"}
+
+// circuitBreakingMiddleware wraps an endpoint in a circuit breaker named after
+// the method. Once the breaker opens, calls fail fast with ErrCircuitOpen until
+// timeout has elapsed, after which up to maxRequests trial calls are let
+// through to decide whether to close it again.
+func circuitBreakingMiddleware(method string, timeout time.Duration, maxRequests uint32) endpoint.Middleware {
+	breaker := circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        method,
+		MaxRequests: maxRequests,
+		Timeout:     timeout,
+	}))
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		// Errors caused by the caller, such as bad input, exceeding the rate
+		// limit, or running out of the time budget it chose, say nothing
+		// about the health of the service. They are smuggled past the
+		// breaker as a successful response.
+		e := breaker(func(ctx context.Context, request interface{}) (interface{}, error) {
+			response, err := next(ctx, request)
+			if err != nil && (codeFrom(err) < http.StatusInternalServerError || isContextError(err)) {
+				return rejected{err}, nil
+			}
+			return response, err
+		})
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			response, err := e(ctx, request)
+			switch {
+			case err == gobreaker.ErrOpenState, err == gobreaker.ErrTooManyRequests:
+				return nil, ErrCircuitOpen
+			case err != nil:
+				return nil, err
+			}
+			if r, ok := response.(rejected); ok {
+				return nil, r.err
+			}
+			return response, nil
+		}
+	}
+}
+
+type rejected struct {
+	err error
+}
+
+// isContextError reports whether err comes from the caller's context being
+// canceled or running past its deadline.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,188 @@
+// Package client provides a Go client for the string service.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/sd"
+	"github.com/go-kit/kit/sd/lb"
+	httptransport "github.com/go-kit/kit/transport/http"
+)
+
+// StringService is the client-side view of the string service.
+type StringService interface {
+	Uppercase(context.Context, string) (string, error)
+	Count(context.Context, string) (Counts, error)
+}
+
+// Counts holds the length of a string measured in bytes and in runes.
+type Counts struct {
+	Bytes int
+	Runes int
+}
+
+// NewHTTPClient returns a StringService backed by the HTTP server at
+// instance, e.g. "localhost:8080" or "https://strings.example.com". It
+// panics if instance isn't a valid URL, which is a programming error.
+func NewHTTPClient(instance string, opts ...httptransport.ClientOption) StringService {
+	u, err := parseInstance(instance)
+	if err != nil {
+		panic(err)
+	}
+	return newHTTPEndpoints(u, opts...)
+}
+
+// NewBalancedClient returns a StringService that spreads calls round-robin
+// across the HTTP servers at instances. Instances that can't be parsed are
+// skipped; if none are left, every call fails with lb.ErrNoEndpoints.
+func NewBalancedClient(instances []string, opts ...httptransport.ClientOption) StringService {
+	var uppercase, count sd.FixedEndpointer
+	for _, instance := range instances {
+		u, err := parseInstance(instance)
+		if err != nil {
+			continue
+		}
+		e := newHTTPEndpoints(u, opts...)
+		uppercase = append(uppercase, e.uppercase)
+		count = append(count, e.count)
+	}
+	return endpoints{
+		uppercase: balanced(lb.NewRoundRobin(uppercase)),
+		count:     balanced(lb.NewRoundRobin(count)),
+	}
+}
+
+// balanced returns an endpoint that forwards each call to the next endpoint
+// picked by b.
+func balanced(b lb.Balancer) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		e, err := b.Endpoint()
+		if err != nil {
+			return nil, err
+		}
+		return e(ctx, request)
+	}
+}
+
+// parseInstance parses an instance address, assuming http if it has no
+// scheme.
+func parseInstance(instance string) (*url.URL, error) {
+	if !strings.HasPrefix(instance, "http") {
+		instance = "http://" + instance
+	}
+	return url.Parse(instance)
+}
+
+func newHTTPEndpoints(u *url.URL, opts ...httptransport.ClientOption) endpoints {
+	return endpoints{
+		uppercase: httptransport.NewClient(
+			"POST",
+			copyURL(u, "/uppercase"),
+			encodeHTTPRequest,
+			decodeUppercaseResponse,
+			opts...,
+		).Endpoint(),
+		count: httptransport.NewClient(
+			"POST",
+			copyURL(u, "/count"),
+			encodeHTTPRequest,
+			decodeCountResponse,
+			opts...,
+		).Endpoint(),
+	}
+}
+
+// endpoints adapts the client endpoints to the StringService interface.
+type endpoints struct {
+	uppercase endpoint.Endpoint
+	count     endpoint.Endpoint
+}
+
+func (e endpoints) Uppercase(ctx context.Context, s string) (string, error) {
+	response, err := e.uppercase(ctx, uppercaseRequest{S: s})
+	if err != nil {
+		return "", err
+	}
+	return response.(uppercaseResponse).V, nil
+}
+
+func (e endpoints) Count(ctx context.Context, s string) (Counts, error) {
+	response, err := e.count(ctx, countRequest{S: s})
+	if err != nil {
+		return Counts{}, err
+	}
+	resp := response.(countResponse)
+	return Counts{Bytes: resp.Bytes, Runes: resp.Runes}, nil
+}
+
+func copyURL(base *url.URL, path string) *url.URL {
+	next := *base
+	next.Path = path
+	return &next
+}
+
+// encodeHTTPRequest JSON-encodes any request type into the request body.
+func encodeHTTPRequest(_ context.Context, r *http.Request, request interface{}) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(request); err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.Body = ioutil.NopCloser(&buf)
+	return nil
+}
+
+func decodeUppercaseResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, decodeError(r)
+	}
+	var resp uppercaseResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func decodeCountResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, decodeError(r)
+	}
+	var resp countResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+// decodeError turns a non-200 response into an error, using the message in
+// the JSON error body when the server sent one.
+func decodeError(r *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Error == "" {
+		return errors.New(r.Status)
+	}
+	return errors.New(body.Error)
+}
+
+type uppercaseRequest struct {
+	S string `json:"s"`
+}
+
+type uppercaseResponse struct {
+	V string `json:"v"`
+}
+
+type countRequest struct {
+	S string `json:"s"`
+}
+
+type countResponse struct {
+	Bytes int `json:"bytes"`
+	Runes int `json:"runes"`
+}
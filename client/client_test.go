@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/go-kit/kit/sd/lb"
+)
+
+// newBackend returns a server that answers /uppercase with name, so that
+// the test can tell which backend served a call.
+func newBackend(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(uppercaseResponse{V: name})
+	}))
+}
+
+func TestBalancedClientRoundRobin(t *testing.T) {
+	a, b := newBackend("a"), newBackend("b")
+	defer a.Close()
+	defer b.Close()
+
+	c := NewBalancedClient([]string{a.URL, b.URL})
+	var got []string
+	for i := 0; i < 6; i++ {
+		v, err := c.Uppercase(context.Background(), "hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	// The round-robin balancer may start on either backend, but must
+	// alternate from there.
+	for i := 1; i < len(got); i++ {
+		if got[i] == got[i-1] {
+			t.Fatalf("backends = %s, want calls to alternate", strings.Join(got, ","))
+		}
+	}
+}
+
+func TestBalancedClientNoInstances(t *testing.T) {
+	c := NewBalancedClient(nil)
+	if _, err := c.Uppercase(context.Background(), "hello"); err != lb.ErrNoEndpoints {
+		t.Errorf("Uppercase with no instances: error = %v, want %v", err, lb.ErrNoEndpoints)
+	}
+}
+
+func TestCount(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req countRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(map[string]int{
+			"v":     len(req.S),
+			"bytes": len(req.S),
+			"runes": utf8.RuneCountInString(req.S),
+		})
+	}))
+	defer srv.Close()
+
+	got, err := NewHTTPClient(srv.URL).Count(context.Background(), "héllo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Counts{Bytes: 6, Runes: 5}); got != want {
+		t.Errorf("Count = %+v, want %+v", got, want)
+	}
+}
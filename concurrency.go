@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics"
+)
+
+// ErrBusy is returned when the service is already handling as many calls as
+// it is allowed to.
+var ErrBusy = &ServiceError{Code: "busy", Status: http.StatusServiceUnavailable, Message: "too many concurrent requests"}
+
+// concurrencyLimitingMiddleware lets at most max calls through the endpoints
+// it wraps at once, failing the rest fast with ErrBusy rather than queuing
+// them. The same middleware must be shared by every endpoint for the limit
+// to be global, or given to a single endpoint to act as a bulkhead for it.
+// The number of calls in flight is reported in inflight.
+func concurrencyLimitingMiddleware(max int, inflight metrics.Gauge) endpoint.Middleware {
+	slots := make(chan struct{}, max)
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			select {
+			case slots <- struct{}{}:
+			default:
+				return nil, ErrBusy
+			}
+			inflight.Add(1)
+			defer func() {
+				inflight.Add(-1)
+				<-slots
+			}()
+			return next(ctx, request)
+		}
+	}
+}
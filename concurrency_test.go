@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+)
+
+// gauge is a metrics.Gauge whose value can be read back.
+type gauge struct {
+	mu sync.Mutex
+	v  float64
+}
+
+func (g *gauge) With(...string) metrics.Gauge { return g }
+
+func (g *gauge) Set(v float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.v = v
+}
+
+func (g *gauge) Add(delta float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.v += delta
+}
+
+func (g *gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.v
+}
+
+// TestBulkheads saturates the count bulkhead and checks that uppercase, which
+// has its own bulkhead, is still served.
+func TestBulkheads(t *testing.T) {
+	const max = 2
+	global := concurrencyLimitingMiddleware(100, discard.NewGauge())
+	bulkhead := func(inflight metrics.Gauge) endpoint.Middleware {
+		return endpoint.Chain(concurrencyLimitingMiddleware(max, inflight), global)
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	blocking := func(ctx context.Context, request interface{}) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		return countResponse{}, nil
+	}
+	countInflight := &gauge{}
+	count := bulkhead(countInflight)(blocking)
+	uppercase := bulkhead(&gauge{})(makeUppercaseEndpoint(stringService{}))
+
+	done := make(chan error, max)
+	for i := 0; i < max; i++ {
+		go func() {
+			_, err := count(context.Background(), countRequest{S: "x"})
+			done <- err
+		}()
+		<-started
+	}
+	defer func() {
+		close(release)
+		for i := 0; i < max; i++ {
+			if err := <-done; err != nil {
+				t.Errorf("blocked count: error = %v", err)
+			}
+		}
+	}()
+
+	if got := countInflight.Value(); got != max {
+		t.Errorf("count in flight = %v, want %v", got, max)
+	}
+	if _, err := count(context.Background(), countRequest{S: "x"}); !errors.Is(err, ErrBusy) {
+		t.Errorf("saturated count: error = %v, want %v", err, ErrBusy)
+	}
+	resp, err := uppercase(context.Background(), uppercaseRequest{S: "hello"})
+	if err != nil {
+		t.Fatalf("uppercase while count is saturated: error = %v", err)
+	}
+	if want := (uppercaseResponse{"HELLO"}); resp != want {
+		t.Errorf("uppercase response = %+v, want %+v", resp, want)
+	}
+}
@@ -0,0 +1,524 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v2"
+)
+
+// config holds the runtime configuration of the service. Settings are read
+// from an optional YAML or JSON file and then from STRINGSVC_* environment
+// variables, which take precedence. Durations can only be set from the
+// environment.
+type config struct {
+	HTTPAddr                string         `json:"http_addr" yaml:"http_addr"`
+	GRPCAddr                string         `json:"grpc_addr" yaml:"grpc_addr"`
+	ThriftAddr              string         `json:"thrift_addr" yaml:"thrift_addr"`
+	MetricsNamespace        string         `json:"metrics_namespace" yaml:"metrics_namespace"`
+	MetricsSubsystem        string         `json:"metrics_subsystem" yaml:"metrics_subsystem"`
+	MetricsLatencyBuckets   []float64      `json:"metrics_latency_buckets" yaml:"metrics_latency_buckets"`
+	MetricsInputSizeBuckets []float64      `json:"metrics_input_size_buckets" yaml:"metrics_input_size_buckets"`
+	MetricsUser             string         `json:"metrics_user" yaml:"metrics_user"`
+	MetricsPass             string         `json:"metrics_pass" yaml:"metrics_pass"`
+	LogLevel                string         `json:"log_level" yaml:"log_level"`
+	LogFormat               string         `json:"log_format" yaml:"log_format"`
+	LogStderr               bool           `json:"log_stderr" yaml:"log_stderr"`
+	LogFile                 string         `json:"log_file" yaml:"log_file"`
+	LogFileMaxSizeMB        int            `json:"log_file_max_size_mb" yaml:"log_file_max_size_mb"`
+	LogFileMaxAgeDays       int            `json:"log_file_max_age_days" yaml:"log_file_max_age_days"`
+	LogFileMaxBackups       int            `json:"log_file_max_backups" yaml:"log_file_max_backups"`
+	AccessLog               bool           `json:"access_log" yaml:"access_log"`
+	ServerTiming            bool           `json:"server_timing" yaml:"server_timing"`
+	OTLPEndpoint            string         `json:"otlp_endpoint" yaml:"otlp_endpoint"`
+	ZipkinURL               string         `json:"zipkin_url" yaml:"zipkin_url"`
+	NATSURL                 string         `json:"nats_url" yaml:"nats_url"`
+	AMQPURL                 string         `json:"amqp_url" yaml:"amqp_url"`
+	RateLimit               float64        `json:"rate_limit" yaml:"rate_limit"`
+	RateBurst               int            `json:"rate_burst" yaml:"rate_burst"`
+	ClientRateLimit         float64        `json:"client_rate_limit" yaml:"client_rate_limit"`
+	ClientRateBurst         int            `json:"client_rate_burst" yaml:"client_rate_burst"`
+	MaxBatchSize            int            `json:"max_batch_size" yaml:"max_batch_size"`
+	MaxInputLength          int            `json:"max_input_length" yaml:"max_input_length"`
+	MaxOutputLength         int            `json:"max_output_length" yaml:"max_output_length"`
+	MaxBodyBytes            int            `json:"max_body_bytes" yaml:"max_body_bytes"`
+	MaxHeaderBytes          int            `json:"max_header_bytes" yaml:"max_header_bytes"`
+	StreamMaxBytes          int            `json:"stream_max_bytes" yaml:"stream_max_bytes"`
+	MaxConcurrent           int            `json:"max_concurrent" yaml:"max_concurrent"`
+	MaxConcurrentPerMethod  int            `json:"max_concurrent_per_method" yaml:"max_concurrent_per_method"`
+	MethodConcurrency       map[string]int `json:"method_concurrency" yaml:"method_concurrency"`
+	CacheSize               int            `json:"cache_size" yaml:"cache_size"`
+	CacheMaxInput           int            `json:"cache_max_input" yaml:"cache_max_input"`
+	IdempotencyMaxKeys      int            `json:"idempotency_max_keys" yaml:"idempotency_max_keys"`
+	RequestTimeout          time.Duration  `json:"-" yaml:"-"`
+	ShutdownTimeout         time.Duration  `json:"-" yaml:"-"`
+	DrainDelay              time.Duration  `json:"-" yaml:"-"`
+	StreamTimeout           time.Duration  `json:"-" yaml:"-"`
+	IdempotencyTTL          time.Duration  `json:"-" yaml:"-"`
+	CORSOrigins             []string       `json:"cors_origins" yaml:"cors_origins"`
+	APIKeys                 []string       `json:"api_keys" yaml:"api_keys"`
+	EnablePprof             bool           `json:"enable_pprof" yaml:"enable_pprof"`
+	TLSCert                 string         `json:"tls_cert" yaml:"tls_cert"`
+	TLSKey                  string         `json:"tls_key" yaml:"tls_key"`
+	ConsulAddr              string         `json:"consul_addr" yaml:"consul_addr"`
+	ConsulServiceName       string         `json:"consul_service_name" yaml:"consul_service_name"`
+	ConsulServiceAddr       string         `json:"consul_service_addr" yaml:"consul_service_addr"`
+}
+
+// methodConcurrency returns how many calls to method may be in flight at
+// once: its entry in MethodConcurrency, or MaxConcurrentPerMethod if it has
+// none. Zero means no per-method limit.
+func (cfg config) methodConcurrency(method string) int {
+	if n, ok := cfg.MethodConcurrency[method]; ok {
+		return n
+	}
+	return cfg.MaxConcurrentPerMethod
+}
+
+// methodNames are the methods that main wraps endpoints for, which are the
+// keys MethodConcurrency accepts.
+var methodNames = map[string]bool{
+	"uppercase": true, "lowercase": true, "count": true, "reverse": true,
+	"trim": true, "uppercase_batch": true, "wordcount": true, "runecount": true,
+	"concat": true, "replace": true, "contains": true, "split": true,
+	"title": true, "pad": true, "capitalize": true, "base64_encode": true,
+	"base64_decode": true, "hash": true, "uppercase_except": true,
+	"charfrequency": true, "distance": true, "repeat": true, "truncate": true,
+	"palindrome": true, "normalize": true, "soundex": true, "countsubstr": true,
+	"convertcase": true, "regex_find": true, "collapse": true,
+	"detectscript": true, "url_encode": true, "url_decode": true,
+	"slugify": true, "mask": true, "linecount": true,
+}
+
+// defaultConfig returns the configuration used when nothing is overridden.
+func defaultConfig() config {
+	return config{
+		HTTPAddr:                ":8080",
+		GRPCAddr:                ":8081",
+		ThriftAddr:              ":8082",
+		MetricsNamespace:        "my_group",
+		MetricsSubsystem:        "string_service",
+		MetricsLatencyBuckets:   stdprometheus.DefBuckets,
+		MetricsInputSizeBuckets: stdprometheus.ExponentialBuckets(1, 2, 17), // 1B to 64KB
+		LogLevel:                "info",
+		LogFormat:               "logfmt",
+		LogStderr:               true,
+		LogFileMaxSizeMB:        100,
+		RateLimit:               100,
+		RateBurst:               100,
+		ClientRateBurst:         10,
+		MaxBatchSize:            100,
+		MaxInputLength:          1 << 20,
+		MaxOutputLength:         16 << 20,
+		MaxBodyBytes:            4 << 20,
+		MaxHeaderBytes:          http.DefaultMaxHeaderBytes,
+		StreamMaxBytes:          1 << 30,
+		MaxConcurrent:           1000,
+		CacheSize:               1024,
+		CacheMaxInput:           4 << 10,
+		IdempotencyMaxKeys:      10000,
+		ConsulServiceName:       "stringsvc",
+		RequestTimeout:          5 * time.Second,
+		ShutdownTimeout:         10 * time.Second,
+		StreamTimeout:           10 * time.Minute,
+		IdempotencyTTL:          10 * time.Minute,
+	}
+}
+
+// loadConfig builds the configuration from the defaults, the file at path
+// (if path is not empty) and the environment, in increasing order of
+// precedence.
+func loadConfig(path string) (config, error) {
+	cfg := defaultConfig()
+	if path != "" {
+		if err := readConfigFile(path, &cfg); err != nil {
+			return config{}, err
+		}
+	}
+
+	cfg.HTTPAddr = envString("STRINGSVC_HTTP_ADDR", cfg.HTTPAddr)
+	cfg.GRPCAddr = envString("STRINGSVC_GRPC_ADDR", cfg.GRPCAddr)
+	cfg.ThriftAddr = envString("STRINGSVC_THRIFT_ADDR", cfg.ThriftAddr)
+	cfg.MetricsNamespace = envString("STRINGSVC_METRICS_NAMESPACE", cfg.MetricsNamespace)
+	cfg.MetricsSubsystem = envString("STRINGSVC_METRICS_SUBSYSTEM", cfg.MetricsSubsystem)
+	cfg.LogLevel = envString("STRINGSVC_LOG_LEVEL", cfg.LogLevel)
+	cfg.LogFormat = envString("STRINGSVC_LOG_FORMAT", cfg.LogFormat)
+	cfg.LogFile = envString("STRINGSVC_LOG_FILE", cfg.LogFile)
+	cfg.OTLPEndpoint = envString("STRINGSVC_OTLP_ENDPOINT", cfg.OTLPEndpoint)
+	cfg.ZipkinURL = envString("STRINGSVC_ZIPKIN_URL", cfg.ZipkinURL)
+	cfg.NATSURL = envString("STRINGSVC_NATS_URL", cfg.NATSURL)
+	cfg.AMQPURL = envString("STRINGSVC_AMQP_URL", cfg.AMQPURL)
+	cfg.CORSOrigins = envList("STRINGSVC_CORS_ORIGINS", cfg.CORSOrigins)
+	cfg.APIKeys = envList("STRINGSVC_API_KEYS", cfg.APIKeys)
+	cfg.MetricsUser = envString("STRINGSVC_METRICS_USER", cfg.MetricsUser)
+	cfg.MetricsPass = envString("STRINGSVC_METRICS_PASS", cfg.MetricsPass)
+	cfg.TLSCert = envString("STRINGSVC_TLS_CERT", cfg.TLSCert)
+	cfg.TLSKey = envString("STRINGSVC_TLS_KEY", cfg.TLSKey)
+	cfg.ConsulAddr = envString("STRINGSVC_CONSUL_ADDR", cfg.ConsulAddr)
+	cfg.ConsulServiceName = envString("STRINGSVC_CONSUL_SERVICE_NAME", cfg.ConsulServiceName)
+	cfg.ConsulServiceAddr = envString("STRINGSVC_CONSUL_SERVICE_ADDR", cfg.ConsulServiceAddr)
+
+	var err error
+	if cfg.MetricsLatencyBuckets, err = envFloatList("STRINGSVC_METRICS_LATENCY_BUCKETS", cfg.MetricsLatencyBuckets); err != nil {
+		return config{}, err
+	}
+	if cfg.MetricsInputSizeBuckets, err = envFloatList("STRINGSVC_METRICS_INPUT_SIZE_BUCKETS", cfg.MetricsInputSizeBuckets); err != nil {
+		return config{}, err
+	}
+	if cfg.RateLimit, err = envFloat("STRINGSVC_RATE_LIMIT", cfg.RateLimit); err != nil {
+		return config{}, err
+	}
+	if cfg.RateBurst, err = envInt("STRINGSVC_RATE_BURST", cfg.RateBurst); err != nil {
+		return config{}, err
+	}
+	if cfg.ClientRateLimit, err = envFloat("STRINGSVC_CLIENT_RATE_LIMIT", cfg.ClientRateLimit); err != nil {
+		return config{}, err
+	}
+	if cfg.ClientRateBurst, err = envInt("STRINGSVC_CLIENT_RATE_BURST", cfg.ClientRateBurst); err != nil {
+		return config{}, err
+	}
+	if cfg.MaxBatchSize, err = envInt("STRINGSVC_MAX_BATCH_SIZE", cfg.MaxBatchSize); err != nil {
+		return config{}, err
+	}
+	if cfg.MaxInputLength, err = envInt("STRINGSVC_MAX_INPUT_LENGTH", cfg.MaxInputLength); err != nil {
+		return config{}, err
+	}
+	if cfg.LogStderr, err = envBool("STRINGSVC_LOG_STDERR", cfg.LogStderr); err != nil {
+		return config{}, err
+	}
+	if cfg.LogFileMaxSizeMB, err = envInt("STRINGSVC_LOG_FILE_MAX_SIZE_MB", cfg.LogFileMaxSizeMB); err != nil {
+		return config{}, err
+	}
+	if cfg.LogFileMaxAgeDays, err = envInt("STRINGSVC_LOG_FILE_MAX_AGE_DAYS", cfg.LogFileMaxAgeDays); err != nil {
+		return config{}, err
+	}
+	if cfg.LogFileMaxBackups, err = envInt("STRINGSVC_LOG_FILE_MAX_BACKUPS", cfg.LogFileMaxBackups); err != nil {
+		return config{}, err
+	}
+	if cfg.AccessLog, err = envBool("STRINGSVC_ACCESS_LOG", cfg.AccessLog); err != nil {
+		return config{}, err
+	}
+	if cfg.ServerTiming, err = envBool("STRINGSVC_SERVER_TIMING", cfg.ServerTiming); err != nil {
+		return config{}, err
+	}
+	if cfg.EnablePprof, err = envBool("STRINGSVC_ENABLE_PPROF", cfg.EnablePprof); err != nil {
+		return config{}, err
+	}
+	if cfg.MaxHeaderBytes, err = envInt("STRINGSVC_MAX_HEADER_BYTES", cfg.MaxHeaderBytes); err != nil {
+		return config{}, err
+	}
+	if cfg.StreamMaxBytes, err = envInt("STRINGSVC_STREAM_MAX_BYTES", cfg.StreamMaxBytes); err != nil {
+		return config{}, err
+	}
+	if cfg.MaxBodyBytes, err = envInt("STRINGSVC_MAX_BODY_BYTES", cfg.MaxBodyBytes); err != nil {
+		return config{}, err
+	}
+	if cfg.MaxOutputLength, err = envInt("STRINGSVC_MAX_OUTPUT_LENGTH", cfg.MaxOutputLength); err != nil {
+		return config{}, err
+	}
+	if cfg.MaxConcurrent, err = envInt("STRINGSVC_MAX_CONCURRENT", cfg.MaxConcurrent); err != nil {
+		return config{}, err
+	}
+	if cfg.MaxConcurrentPerMethod, err = envInt("STRINGSVC_MAX_CONCURRENT_PER_METHOD", cfg.MaxConcurrentPerMethod); err != nil {
+		return config{}, err
+	}
+	if cfg.MethodConcurrency, err = envIntMap("STRINGSVC_METHOD_CONCURRENCY", cfg.MethodConcurrency); err != nil {
+		return config{}, err
+	}
+	if cfg.CacheSize, err = envInt("STRINGSVC_CACHE_SIZE", cfg.CacheSize); err != nil {
+		return config{}, err
+	}
+	if cfg.CacheMaxInput, err = envInt("STRINGSVC_CACHE_MAX_INPUT", cfg.CacheMaxInput); err != nil {
+		return config{}, err
+	}
+	if cfg.IdempotencyMaxKeys, err = envInt("STRINGSVC_IDEMPOTENCY_MAX_KEYS", cfg.IdempotencyMaxKeys); err != nil {
+		return config{}, err
+	}
+	if cfg.RequestTimeout, err = envDuration("STRINGSVC_REQUEST_TIMEOUT", cfg.RequestTimeout); err != nil {
+		return config{}, err
+	}
+	if cfg.ShutdownTimeout, err = envDuration("STRINGSVC_SHUTDOWN_TIMEOUT", cfg.ShutdownTimeout); err != nil {
+		return config{}, err
+	}
+	if cfg.IdempotencyTTL, err = envDuration("STRINGSVC_IDEMPOTENCY_TTL", cfg.IdempotencyTTL); err != nil {
+		return config{}, err
+	}
+	if cfg.DrainDelay, err = envDuration("STRINGSVC_DRAIN_DELAY", cfg.DrainDelay); err != nil {
+		return config{}, err
+	}
+	if cfg.StreamTimeout, err = envDuration("STRINGSVC_STREAM_TIMEOUT", cfg.StreamTimeout); err != nil {
+		return config{}, err
+	}
+	return cfg, cfg.validate()
+}
+
+// readConfigFile decodes the YAML or JSON file at path into cfg, choosing the
+// format by file extension. Unknown keys are rejected so that typos don't
+// go unnoticed.
+func readConfigFile(path string, cfg *config) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, cfg)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+	default:
+		return fmt.Errorf("%s: unsupported config file extension %q", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// validate reports the first setting that can't be used.
+func (cfg config) validate() error {
+	switch {
+	case cfg.HTTPAddr == "":
+		return errors.New("http_addr must not be empty")
+	case cfg.GRPCAddr == "":
+		return errors.New("grpc_addr must not be empty")
+	case cfg.ThriftAddr == "":
+		return errors.New("thrift_addr must not be empty")
+	case !increasing(cfg.MetricsLatencyBuckets):
+		return errors.New("metrics_latency_buckets must be increasing")
+	case !increasing(cfg.MetricsInputSizeBuckets):
+		return errors.New("metrics_input_size_buckets must be increasing")
+	case !cfg.LogStderr && cfg.LogFile == "":
+		return errors.New("log_file must be set when log_stderr is off")
+	case cfg.LogFileMaxSizeMB <= 0:
+		return errors.New("log_file_max_size_mb must be positive")
+	case cfg.LogFileMaxAgeDays < 0:
+		return errors.New("log_file_max_age_days must not be negative")
+	case cfg.LogFileMaxBackups < 0:
+		return errors.New("log_file_max_backups must not be negative")
+	case cfg.RateLimit <= 0:
+		return errors.New("rate_limit must be positive")
+	case cfg.RateBurst <= 0:
+		return errors.New("rate_burst must be positive")
+	case cfg.ClientRateLimit < 0:
+		return errors.New("client_rate_limit must not be negative")
+	case cfg.ClientRateLimit > 0 && cfg.ClientRateBurst <= 0:
+		return errors.New("client_rate_burst must be positive")
+	case cfg.MaxBatchSize <= 0:
+		return errors.New("max_batch_size must be positive")
+	case cfg.MaxInputLength <= 0:
+		return errors.New("max_input_length must be positive")
+	case cfg.MaxHeaderBytes <= 0:
+		return errors.New("max_header_bytes must be positive")
+	case cfg.MaxBodyBytes <= 0:
+		return errors.New("max_body_bytes must be positive")
+	case cfg.MaxOutputLength <= 0:
+		return errors.New("max_output_length must be positive")
+	case cfg.MaxConcurrent <= 0:
+		return errors.New("max_concurrent must be positive")
+	case cfg.MaxConcurrentPerMethod < 0:
+		return errors.New("max_concurrent_per_method must not be negative")
+	case !allPositive(cfg.MethodConcurrency):
+		return errors.New("method_concurrency limits must be positive")
+	case cfg.CacheSize < 0:
+		return errors.New("cache_size must not be negative")
+	case cfg.CacheMaxInput < 0:
+		return errors.New("cache_max_input must not be negative")
+	case cfg.RequestTimeout <= 0:
+		return errors.New("request timeout must be positive")
+	case cfg.ShutdownTimeout <= 0:
+		return errors.New("shutdown timeout must be positive")
+	case cfg.IdempotencyTTL <= 0:
+		return errors.New("idempotency ttl must be positive")
+	case cfg.IdempotencyMaxKeys <= 0:
+		return errors.New("idempotency_max_keys must be positive")
+	case cfg.DrainDelay < 0:
+		return errors.New("drain delay must not be negative")
+	case cfg.StreamMaxBytes <= 0:
+		return errors.New("stream_max_bytes must be positive")
+	case cfg.StreamTimeout <= 0:
+		return errors.New("stream timeout must be positive")
+	case cfg.ConsulAddr != "" && cfg.ConsulServiceName == "":
+		return errors.New("consul_service_name must not be empty")
+	case (cfg.MetricsUser == "") != (cfg.MetricsPass == ""):
+		return errors.New("metrics_user and metrics_pass must be set together")
+	case (cfg.TLSCert == "") != (cfg.TLSKey == ""):
+		return errors.New("tls_cert and tls_key must be set together")
+	}
+	if m := unknownMethod(cfg.MethodConcurrency); m != "" {
+		return fmt.Errorf("method_concurrency: unknown method %q", m)
+	}
+	return nil
+}
+
+func envString(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return fallback
+}
+
+// envList splits a comma-separated variable into its non-empty elements.
+func envList(key string, fallback []string) []string {
+	v := envString(key, "")
+	if v == "" {
+		return fallback
+	}
+	var list []string
+	for _, v := range strings.Split(v, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func envFloat(key string, fallback float64) (float64, error) {
+	v := envString(key, "")
+	if v == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", key, err)
+	}
+	return f, nil
+}
+
+// envFloatList reads a comma-separated list of numbers.
+func envFloatList(key string, fallback []float64) ([]float64, error) {
+	list := envList(key, nil)
+	if list == nil {
+		return fallback, nil
+	}
+	fs := make([]float64, len(list))
+	for i, v := range list {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		fs[i] = f
+	}
+	return fs, nil
+}
+
+// envIntMap reads a comma-separated list of name=number pairs, such as
+// "count=50,uppercase=200".
+func envIntMap(key string, fallback map[string]int) (map[string]int, error) {
+	list := envList(key, nil)
+	if list == nil {
+		return fallback, nil
+	}
+	m := make(map[string]int, len(list))
+	for _, v := range list {
+		name, n, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s: %q is not name=number", key, v)
+		}
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		m[strings.TrimSpace(name)] = i
+	}
+	return m, nil
+}
+
+func envInt(key string, fallback int) (int, error) {
+	v := envString(key, "")
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", key, err)
+	}
+	return n, nil
+}
+
+func envBool(key string, fallback bool) (bool, error) {
+	v := envString(key, "")
+	if v == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s: %v", key, err)
+	}
+	return b, nil
+}
+
+func envDuration(key string, fallback time.Duration) (time.Duration, error) {
+	v := envString(key, "")
+	if v == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", key, err)
+	}
+	return d, nil
+}
+
+// allPositive reports whether every value in m is positive.
+func allPositive(m map[string]int) bool {
+	for _, v := range m {
+		if v <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// unknownMethod returns the first key of m, in sorted order, that isn't in
+// methodNames, or "" if they all are.
+func unknownMethod(m map[string]int) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !methodNames[k] {
+			return k
+		}
+	}
+	return ""
+}
+
+// increasing reports whether fs is a non-empty, strictly increasing list,
+// as Prometheus requires of histogram buckets.
+func increasing(fs []float64) bool {
+	if len(fs) == 0 {
+		return false
+	}
+	for i := 1; i < len(fs); i++ {
+		if fs[i] <= fs[i-1] {
+			return false
+		}
+	}
+	return true
+}
+
+// redactURL hides the password in s, such as the one in an AMQP URL, so that
+// it can be logged.
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
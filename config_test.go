@@ -0,0 +1,20 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateMethodConcurrency(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.MethodConcurrency = map[string]int{"uppercase": 10, "count": 5}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate() with known methods = %v, want nil", err)
+	}
+
+	cfg.MethodConcurrency["upercase"] = 10
+	err := cfg.validate()
+	if err == nil || !strings.Contains(err.Error(), `"upercase"`) {
+		t.Errorf("validate() with a misspelt method = %v, want an error naming it", err)
+	}
+}
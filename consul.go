@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/go-kit/kit/log"
+	consulsd "github.com/go-kit/kit/sd/consul"
+	"github.com/hashicorp/consul/api"
+)
+
+// consulCheckInterval is how often Consul polls /health.
+const consulCheckInterval = "10s"
+
+// newConsulRegistrar returns a registrar for this instance with the Consul
+// agent at cfg.ConsulAddr. The instance is advertised at
+// cfg.ConsulServiceAddr, or at this host's name and the HTTP port if that
+// is empty, and Consul checks its health through /health.
+func newConsulRegistrar(cfg config, logger log.Logger) (*consulsd.Registrar, error) {
+	consulCfg := api.DefaultConfig()
+	consulCfg.Address = cfg.ConsulAddr
+	client, err := api.NewClient(consulCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := cfg.ConsulServiceAddr
+	if addr == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		_, port, err := net.SplitHostPort(cfg.HTTPAddr)
+		if err != nil {
+			return nil, err
+		}
+		addr = net.JoinHostPort(host, port)
+	}
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("consul service port %q: %v", portStr, err)
+	}
+
+	scheme := "http"
+	if cfg.TLSCert != "" {
+		scheme = "https"
+	}
+	registration := &api.AgentServiceRegistration{
+		ID:      cfg.ConsulServiceName + "-" + addr,
+		Name:    cfg.ConsulServiceName,
+		Address: host,
+		Port:    port,
+		Check: &api.AgentServiceCheck{
+			HTTP:          fmt.Sprintf("%s://%s/health", scheme, addr),
+			Interval:      consulCheckInterval,
+			TLSSkipVerify: cfg.TLSCert != "",
+		},
+	}
+	return consulsd.NewRegistrar(consulsd.NewClient(client), registration, logger), nil
+}
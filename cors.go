@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+var (
+	corsAllowedMethods = []string{"GET", "POST", "OPTIONS"}
+	corsAllowedHeaders = []string{"Content-Type", "Accept", requestIDHeader, apiKeyHeader, idempotencyKeyHeader, requestTimeoutHeader, "traceparent", "tracestate"}
+	corsExposedHeaders = []string{requestIDHeader, serverTimingHeader}
+)
+
+// corsHandler adds CORS headers to responses for requests from any of the
+// allowed origins, and answers preflight requests without passing them on to
+// h. An allowed origin of "*" matches every origin.
+func corsHandler(h http.Handler, allowedOrigins []string) http.Handler {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[origin] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && (allowed["*"] || allowed[origin]) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+			w.Header().Add("Vary", "Origin")
+		}
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(corsAllowedMethods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(corsAllowedHeaders, ", "))
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// drainingMiddleware reports the service as unhealthy once draining is set,
+// so that load balancers stop sending it new traffic while in-flight
+// requests finish. Every other method goes straight to the embedded
+// StringService.
+type drainingMiddleware struct {
+	StringService
+	draining *atomic.Bool
+}
+
+// newDrainingMiddleware returns a Middleware that fails Health while
+// draining is set.
+func newDrainingMiddleware(draining *atomic.Bool) Middleware {
+	return func(next StringService) StringService {
+		return drainingMiddleware{next, draining}
+	}
+}
+
+func (mw drainingMiddleware) Health(ctx context.Context) (bool, error) {
+	if mw.draining.Load() {
+		return false, nil
+	}
+	return mw.StringService.Health(ctx)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/AndrewSC208/StringService/pb"
+)
+
+type grpcServer struct {
+	uppercase grpctransport.Handler
+	count     grpctransport.Handler
+}
+
+// newGRPCServer makes the Uppercase and Count endpoints available as a gRPC
+// StringServiceServer.
+func newGRPCServer(uppercase, count endpoint.Endpoint) pb.StringServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerBefore(populateGRPCAPIKey),
+	}
+	return &grpcServer{
+		uppercase: grpctransport.NewServer(
+			uppercase,
+			decodeGRPCUppercaseRequest,
+			encodeGRPCUppercaseResponse,
+			options...,
+		),
+		count: grpctransport.NewServer(
+			count,
+			decodeGRPCCountRequest,
+			encodeGRPCCountResponse,
+			options...,
+		),
+	}
+}
+
+func (s *grpcServer) Uppercase(ctx oldcontext.Context, req *pb.UppercaseRequest) (*pb.UppercaseReply, error) {
+	_, rep, err := s.uppercase.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return rep.(*pb.UppercaseReply), nil
+}
+
+func (s *grpcServer) Count(ctx oldcontext.Context, req *pb.CountRequest) (*pb.CountReply, error) {
+	_, rep, err := s.count.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return rep.(*pb.CountReply), nil
+}
+
+// grpcError converts a service error into a gRPC status error, the gRPC
+// counterpart of codeFrom.
+func grpcError(err error) error {
+	code := codes.Unknown
+	var se *ServiceError
+	switch {
+	case errors.As(err, &se):
+		code = grpcCode(se.Status)
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	}
+	return status.Error(code, err.Error())
+}
+
+// grpcCode gives the gRPC code that corresponds to the HTTP status of a
+// ServiceError.
+func grpcCode(status int) codes.Code {
+	switch status {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusInternalServerError:
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+func decodeGRPCUppercaseRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.UppercaseRequest)
+	return uppercaseRequest{S: req.S}, nil
+}
+
+func decodeGRPCCountRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CountRequest)
+	return countRequest{S: req.S}, nil
+}
+
+func encodeGRPCUppercaseResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(uppercaseResponse)
+	return &pb.UppercaseReply{V: resp.V}, nil
+}
+
+func encodeGRPCCountResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(countResponse)
+	return &pb.CountReply{V: int64(resp.V)}, nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/hashicorp/golang-lru/simplelru"
+)
+
+// idempotencyKeyHeader carries a client-chosen key that makes retries of a
+// call safe: a repeat with the same key gets the first response back.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is sent again
+// with a different request.
+var ErrIdempotencyKeyReused = &ServiceError{Code: "idempotency_key_reused", Status: http.StatusUnprocessableEntity, Message: "idempotency key reused with a different request"}
+
+// populateIdempotencyKey is a ServerBefore hook that stores the
+// Idempotency-Key header in the context for idempotencyMiddleware.
+func populateIdempotencyKey(ctx context.Context, r *http.Request) context.Context {
+	if key := r.Header.Get(idempotencyKeyHeader); key != "" {
+		return context.WithValue(ctx, idempotencyKeyContextKey, key)
+	}
+	return ctx
+}
+
+// idempotencyStore remembers the responses to calls made with an
+// idempotency key for ttl. At most size keys are kept; beyond that the least
+// recently used are forgotten first. Expired entries are evicted every ttl
+// until close is called.
+type idempotencyStore struct {
+	ttl  time.Duration
+	done chan struct{}
+
+	mu      sync.Mutex
+	entries *simplelru.LRU
+}
+
+// idempotencyEntryKey scopes an idempotency key to the method and the
+// caller's API key, so that callers can't see each other's responses.
+type idempotencyEntryKey struct {
+	method string
+	apiKey string
+	key    string
+}
+
+// idempotencyEntry is the outcome of the first call made with a key. done is
+// closed once response and err are set; until then the call is in flight and
+// the entry doesn't expire.
+type idempotencyEntry struct {
+	request  interface{}
+	done     chan struct{}
+	response interface{}
+	err      error
+	expires  time.Time
+}
+
+func (e *idempotencyEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+func newIdempotencyStore(ttl time.Duration, size int) (*idempotencyStore, error) {
+	entries, err := simplelru.NewLRU(size, nil)
+	if err != nil {
+		return nil, err
+	}
+	s := &idempotencyStore{
+		ttl:     ttl,
+		done:    make(chan struct{}),
+		entries: entries,
+	}
+	go s.evictExpired()
+	return s, nil
+}
+
+func (s *idempotencyStore) evictExpired() {
+	t := time.NewTicker(s.ttl)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-t.C:
+			s.mu.Lock()
+			for _, k := range s.entries.Keys() {
+				if e, ok := s.entries.Peek(k); ok && e.(*idempotencyEntry).expired(now) {
+					s.entries.Remove(k)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// close stops the eviction of expired entries.
+func (s *idempotencyStore) close() {
+	close(s.done)
+}
+
+// claim returns the live entry for k, or, if there is none, stores a new
+// in-flight entry for request and returns it with first set.
+func (s *idempotencyStore) claim(k idempotencyEntryKey, request interface{}) (e *idempotencyEntry, first bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.entries.Get(k); ok && !v.(*idempotencyEntry).expired(time.Now()) {
+		return v.(*idempotencyEntry), false
+	}
+	e = &idempotencyEntry{request: request, done: make(chan struct{})}
+	s.entries.Add(k, e)
+	return e, true
+}
+
+// finish records the outcome of the call that claimed e. A failed call is
+// forgotten so that it can be retried with the same key.
+func (s *idempotencyStore) finish(k idempotencyEntryKey, e *idempotencyEntry, response interface{}, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.response, e.err = response, err
+	if err != nil {
+		if v, ok := s.entries.Peek(k); ok && v == e {
+			s.entries.Remove(k)
+		}
+	} else {
+		e.expires = time.Now().Add(s.ttl)
+	}
+	close(e.done)
+}
+
+// idempotencyMiddleware answers calls that carry an idempotency key seen
+// within the store's TTL with the response to the first such call, without
+// calling the endpoint again. Calls that arrive while the first is still in
+// flight wait for it and share its outcome. Only successful responses are
+// remembered, so a failed call can be retried with the same key. Calls
+// without a key pass straight through.
+func idempotencyMiddleware(store *idempotencyStore, method string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			key, _ := ctx.Value(idempotencyKeyContextKey).(string)
+			if key == "" {
+				return next(ctx, request)
+			}
+			apiKey, _ := ctx.Value(apiKeyContextKey).(string)
+			k := idempotencyEntryKey{method, apiKey, key}
+
+			e, first := store.claim(k, request)
+			if !first {
+				if !reflect.DeepEqual(e.request, request) {
+					return nil, ErrIdempotencyKeyReused
+				}
+				select {
+				case <-e.done:
+					return e.response, e.err
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}
+
+			// If next panics, release the waiters before the panic reaches
+			// recoveryMiddleware.
+			finished := false
+			defer func() {
+				if !finished {
+					store.finish(k, e, nil, ErrInternal)
+				}
+			}()
+			response, err := next(ctx, request)
+			finished = true
+			store.finish(k, e, response, err)
+			return response, err
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/go-kit/kit/metrics"
@@ -11,28 +14,556 @@ type instrumentingMiddleware struct {
 	requestCount   metrics.Counter
 	requestLatency metrics.Histogram
 	countResult    metrics.Histogram
+	batchSize      metrics.Histogram
+	inputSize      metrics.Histogram
+	errorCount     metrics.Counter
 	next           StringService
 }
 
-func (mw instrumentingMiddleware) Uppercase(s string) (output string, err error) {
+// newInstrumentingMiddleware returns a Middleware that records every call in
+// the given metrics.
+func newInstrumentingMiddleware(requestCount metrics.Counter, requestLatency, countResult, batchSize, inputSize metrics.Histogram, errorCount metrics.Counter) Middleware {
+	return func(next StringService) StringService {
+		return instrumentingMiddleware{requestCount, requestLatency, countResult, batchSize, inputSize, errorCount, next}
+	}
+}
+
+// observeResult records the value returned by a method with an integer
+// result, labeled by method.
+func (mw instrumentingMiddleware) observeResult(method string, n int, err error) {
+	mw.countResult.With("method", method, "error", fmt.Sprint(err != nil)).Observe(float64(n))
+}
+
+// errorType gives the error_type label for err: the Code of a ServiceError,
+// or the kind of context error. Other errors are counted as "unknown".
+func errorType(err error) string {
+	var se *ServiceError
+	switch {
+	case errors.As(err, &se):
+		return se.Code
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	}
+	return "unknown"
+}
+
+// countError counts a failed call by method and error type.
+func (mw instrumentingMiddleware) countError(method string, err error) {
+	if err == nil {
+		return
+	}
+	mw.errorCount.With("method", method, "error_type", errorType(err)).Add(1)
+}
+
+func (mw instrumentingMiddleware) Uppercase(ctx context.Context, s string) (output string, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "uppercase", "error", fmt.Sprint(err != nil)}
 		mw.requestCount.With(lvs...).Add(1)
 		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("uppercase", err)
+		mw.inputSize.With("method", "uppercase").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Uppercase(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) Lowercase(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "lowercase", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("lowercase", err)
+		mw.inputSize.With("method", "lowercase").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Lowercase(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) Count(ctx context.Context, s string) (c Counts, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "count", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("count", err)
+		mw.inputSize.With("method", "count").Observe(float64(len(s)))
+		mw.observeResult("count", c.Bytes, err)
+	}(time.Now())
+
+	c, err = mw.next.Count(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) Reverse(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "reverse", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("reverse", err)
+		mw.inputSize.With("method", "reverse").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Reverse(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) Trim(ctx context.Context, s string, cutset string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "trim", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("trim", err)
+		mw.inputSize.With("method", "trim").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Trim(ctx, s, cutset)
+	return
+}
+
+func (mw instrumentingMiddleware) Health(ctx context.Context) (healthy bool, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "health", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("health", err)
+	}(time.Now())
+
+	healthy, err = mw.next.Health(ctx)
+	return
+}
+
+func (mw instrumentingMiddleware) UppercaseBatch(ctx context.Context, ss []string) (output []string, errs []error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "uppercase_batch", "error", fmt.Sprint(countErrors(errs) > 0)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.batchSize.Observe(float64(len(ss)))
+		for _, err := range errs {
+			mw.countError("uppercase_batch", err)
+		}
+	}(time.Now())
+
+	output, errs = mw.next.UppercaseBatch(ctx, ss)
+	return
+}
+
+func (mw instrumentingMiddleware) WordCount(ctx context.Context, s string) (n int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "wordcount", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("wordcount", err)
+		mw.inputSize.With("method", "wordcount").Observe(float64(len(s)))
+		mw.observeResult("wordcount", n, err)
+	}(time.Now())
+
+	n, err = mw.next.WordCount(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) RuneCount(ctx context.Context, s string) (n int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "runecount", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("runecount", err)
+		mw.inputSize.With("method", "runecount").Observe(float64(len(s)))
+		mw.observeResult("runecount", n, err)
+	}(time.Now())
+
+	n, err = mw.next.RuneCount(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) Concat(ctx context.Context, ss []string, sep string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "concat", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("concat", err)
+	}(time.Now())
+
+	output, err = mw.next.Concat(ctx, ss, sep)
+	return
+}
+
+func (mw instrumentingMiddleware) Replace(ctx context.Context, s, old, new string, n int) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "replace", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("replace", err)
+		mw.inputSize.With("method", "replace").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Replace(ctx, s, old, new, n)
+	return
+}
+
+func (mw instrumentingMiddleware) Contains(ctx context.Context, s, substr string) (found bool, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "contains", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("contains", err)
+		mw.inputSize.With("method", "contains").Observe(float64(len(s)))
+	}(time.Now())
+
+	found, err = mw.next.Contains(ctx, s, substr)
+	return
+}
+
+func (mw instrumentingMiddleware) Split(ctx context.Context, s, sep string) (parts []string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "split", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("split", err)
+		mw.inputSize.With("method", "split").Observe(float64(len(s)))
+	}(time.Now())
+
+	parts, err = mw.next.Split(ctx, s, sep)
+	return
+}
+
+func (mw instrumentingMiddleware) Title(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "title", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("title", err)
+		mw.inputSize.With("method", "title").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Title(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) Version(ctx context.Context) (info BuildInfo, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "version", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("version", err)
+	}(time.Now())
+
+	info, err = mw.next.Version(ctx)
+	return
+}
+
+func (mw instrumentingMiddleware) Pad(ctx context.Context, s string, width int, pad string, left bool) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "pad", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("pad", err)
+		mw.inputSize.With("method", "pad").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Pad(ctx, s, width, pad, left)
+	return
+}
+
+func (mw instrumentingMiddleware) Capitalize(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "capitalize", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("capitalize", err)
+		mw.inputSize.With("method", "capitalize").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Capitalize(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) Base64Encode(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "base64_encode", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("base64_encode", err)
+		mw.inputSize.With("method", "base64_encode").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Base64Encode(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) Base64Decode(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "base64_decode", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("base64_decode", err)
+		mw.inputSize.With("method", "base64_decode").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Base64Decode(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) Hash(ctx context.Context, s, algo string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "hash", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("hash", err)
+		mw.inputSize.With("method", "hash").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Hash(ctx, s, algo)
+	return
+}
+
+func (mw instrumentingMiddleware) UppercaseExcept(ctx context.Context, s, except string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "uppercase_except", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("uppercase_except", err)
+		mw.inputSize.With("method", "uppercase_except").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.UppercaseExcept(ctx, s, except)
+	return
+}
+
+func (mw instrumentingMiddleware) CharFrequency(ctx context.Context, s string) (freq map[string]int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "charfrequency", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("charfrequency", err)
+		mw.inputSize.With("method", "charfrequency").Observe(float64(len(s)))
+	}(time.Now())
+
+	freq, err = mw.next.CharFrequency(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) Distance(ctx context.Context, a, b string) (n int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "distance", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("distance", err)
+		mw.observeResult("distance", n, err)
+	}(time.Now())
+
+	n, err = mw.next.Distance(ctx, a, b)
+	return
+}
+
+func (mw instrumentingMiddleware) Repeat(ctx context.Context, s string, count int) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "repeat", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("repeat", err)
+		mw.inputSize.With("method", "repeat").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Repeat(ctx, s, count)
+	return
+}
+
+func (mw instrumentingMiddleware) Truncate(ctx context.Context, s string, max int, ellipsis bool) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "truncate", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("truncate", err)
+		mw.inputSize.With("method", "truncate").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Truncate(ctx, s, max, ellipsis)
+	return
+}
+
+func (mw instrumentingMiddleware) IsPalindrome(ctx context.Context, s string, strip bool) (palindrome bool, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "palindrome", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("palindrome", err)
+		mw.inputSize.With("method", "palindrome").Observe(float64(len(s)))
+	}(time.Now())
+
+	palindrome, err = mw.next.IsPalindrome(ctx, s, strip)
+	return
+}
+
+func (mw instrumentingMiddleware) Normalize(ctx context.Context, s, form string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "normalize", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("normalize", err)
+		mw.inputSize.With("method", "normalize").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Normalize(ctx, s, form)
+	return
+}
+
+func (mw instrumentingMiddleware) Soundex(ctx context.Context, s string) (code string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "soundex", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("soundex", err)
+		mw.inputSize.With("method", "soundex").Observe(float64(len(s)))
+	}(time.Now())
+
+	code, err = mw.next.Soundex(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) CountSubstr(ctx context.Context, s, substr string) (n int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "countsubstr", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("countsubstr", err)
+		mw.inputSize.With("method", "countsubstr").Observe(float64(len(s)))
+		mw.observeResult("countsubstr", n, err)
+	}(time.Now())
+
+	n, err = mw.next.CountSubstr(ctx, s, substr)
+	return
+}
+
+func (mw instrumentingMiddleware) ConvertCase(ctx context.Context, s, to string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "convertcase", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("convertcase", err)
+		mw.inputSize.With("method", "convertcase").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.ConvertCase(ctx, s, to)
+	return
+}
+
+func (mw instrumentingMiddleware) RegexFind(ctx context.Context, s, pattern string) (matches []string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "regex_find", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("regex_find", err)
+		mw.inputSize.With("method", "regex_find").Observe(float64(len(s)))
+	}(time.Now())
+
+	matches, err = mw.next.RegexFind(ctx, s, pattern)
+	return
+}
+
+func (mw instrumentingMiddleware) CollapseSpaces(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "collapse", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("collapse", err)
+		mw.inputSize.With("method", "collapse").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.CollapseSpaces(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) DetectScript(ctx context.Context, s string) (script string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "detectscript", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("detectscript", err)
+		mw.inputSize.With("method", "detectscript").Observe(float64(len(s)))
+	}(time.Now())
+
+	script, err = mw.next.DetectScript(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) URLEncode(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "url_encode", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("url_encode", err)
+		mw.inputSize.With("method", "url_encode").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.URLEncode(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) URLDecode(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "url_decode", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("url_decode", err)
+		mw.inputSize.With("method", "url_decode").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.URLDecode(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) Slugify(ctx context.Context, s string) (slug string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "slugify", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("slugify", err)
+		mw.inputSize.With("method", "slugify").Observe(float64(len(s)))
+	}(time.Now())
+
+	slug, err = mw.next.Slugify(ctx, s)
+	return
+}
+
+func (mw instrumentingMiddleware) Mask(ctx context.Context, s string, visible int, mask string) (output string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "mask", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("mask", err)
+		mw.inputSize.With("method", "mask").Observe(float64(len(s)))
+	}(time.Now())
+
+	output, err = mw.next.Mask(ctx, s, visible, mask)
+	return
+}
+
+func (mw instrumentingMiddleware) LineCount(ctx context.Context, s string) (lines int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "linecount", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		mw.countError("linecount", err)
+		mw.inputSize.With("method", "linecount").Observe(float64(len(s)))
+		mw.observeResult("linecount", lines, err)
 	}(time.Now())
 
-	output, err = mw.next.Uppercase(s)
+	lines, err = mw.next.LineCount(ctx, s)
 	return
 }
 
-func (mw instrumentingMiddleware) Count(s string) (n int) {
+func (mw instrumentingMiddleware) UppercaseStream(ctx context.Context, dst io.Writer, src io.Reader) (n int64, err error) {
 	defer func(begin time.Time) {
-		lvs := []string{"method", "count", "error", "false"}
+		lvs := []string{"method", "uppercase_stream", "error", fmt.Sprint(err != nil)}
 		mw.requestCount.With(lvs...).Add(1)
 		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
-		mw.countResult.Observe(float64(n))
+		mw.countError("uppercase_stream", err)
+		mw.inputSize.With("method", "uppercase_stream").Observe(float64(n))
 	}(time.Now())
 
-	n = mw.next.Count(s)
+	n, err = mw.next.UppercaseStream(ctx, dst, src)
 	return
 }
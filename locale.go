@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/text/language"
+)
+
+// populateLanguage is a ServerBefore hook that stores the caller's preferred
+// language, the first one in the Accept-Language header, in the context.
+// A missing or malformed header, or one asking for any language, leaves the
+// context without a language.
+func populateLanguage(ctx context.Context, r *http.Request) context.Context {
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil || len(tags) == 0 || tags[0] == language.Und {
+		return ctx
+	}
+	return context.WithValue(ctx, languageContextKey, tags[0])
+}
+
+// languageFromContext returns the language stored by populateLanguage, if
+// any.
+func languageFromContext(ctx context.Context) (language.Tag, bool) {
+	tag, ok := ctx.Value(languageContextKey).(language.Tag)
+	return tag, ok
+}
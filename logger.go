@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+)
+
+// newLogWriter returns where logs should go according to cfg: stderr, a
+// file rotated once it reaches LogFileMaxSizeMB, or both. Closing it closes
+// the file.
+func newLogWriter(cfg config) io.WriteCloser {
+	var (
+		ws   []io.Writer
+		file *lumberjack.Logger
+	)
+	if cfg.LogStderr {
+		ws = append(ws, os.Stderr)
+	}
+	if cfg.LogFile != "" {
+		file = &lumberjack.Logger{
+			Filename:   cfg.LogFile,
+			MaxSize:    cfg.LogFileMaxSizeMB,
+			MaxAge:     cfg.LogFileMaxAgeDays,
+			MaxBackups: cfg.LogFileMaxBackups,
+		}
+		ws = append(ws, file)
+	}
+	return logWriter{io.MultiWriter(ws...), file}
+}
+
+type logWriter struct {
+	io.Writer
+	file *lumberjack.Logger
+}
+
+func (w logWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
+
+// newLogger returns a logger writing to w in the named format, "logfmt" or
+// "json".
+func newLogger(format string, w io.Writer) (log.Logger, error) {
+	switch strings.ToLower(format) {
+	case "logfmt":
+		return log.NewLogfmtLogger(w), nil
+	case "json":
+		return log.NewJSONLogger(w), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+// levelFilter maps a log level name to the option that allows that level and
+// everything above it.
+func levelFilter(name string) (level.Option, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return level.AllowDebug(), nil
+	case "info":
+		return level.AllowInfo(), nil
+	case "warn":
+		return level.AllowWarn(), nil
+	case "error":
+		return level.AllowError(), nil
+	}
+	return nil, fmt.Errorf("unknown log level %q", name)
+}
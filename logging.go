@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
+	"io"
 	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 )
 
 type loggingMiddleware struct {
@@ -11,9 +14,26 @@ type loggingMiddleware struct {
 	next   StringService
 }
 
-func (mw loggingMiddleware) Uppercase(s string) (output string, err error) {
+// newLoggingMiddleware returns a Middleware that logs every call to logger.
+func newLoggingMiddleware(logger log.Logger) Middleware {
+	return func(next StringService) StringService {
+		return loggingMiddleware{logger, next}
+	}
+}
+
+// leveled returns a logger that logs failed calls at error level and
+// successful ones at info level, tagged with the request ID from ctx.
+func (mw loggingMiddleware) leveled(ctx context.Context, err error) log.Logger {
+	logger := log.With(mw.logger, "request_id", requestIDFromContext(ctx))
+	if err != nil {
+		return level.Error(logger)
+	}
+	return level.Info(logger)
+}
+
+func (mw loggingMiddleware) Uppercase(ctx context.Context, s string) (output string, err error) {
 	defer func(begin time.Time) {
-		_ = mw.logger.Log(
+		_ = mw.leveled(ctx, err).Log(
 			"method", "uppercase",
 			"input", s,
 			"output", output,
@@ -22,20 +42,596 @@ func (mw loggingMiddleware) Uppercase(s string) (output string, err error) {
 		)
 	}(time.Now())
 
-	output, err = mw.next.Uppercase(s)
+	output, err = mw.next.Uppercase(ctx, s)
 	return
 }
 
-func (mw loggingMiddleware) Count(s string) (n int) {
+func (mw loggingMiddleware) Lowercase(ctx context.Context, s string) (output string, err error) {
 	defer func(begin time.Time) {
-		_ = mw.logger.Log(
+		_ = mw.leveled(ctx, err).Log(
+			"method", "lowercase",
+			"input", s,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Lowercase(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) Count(ctx context.Context, s string) (c Counts, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
 			"method", "count",
 			"input", s,
+			"bytes", c.Bytes,
+			"runes", c.Runes,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	c, err = mw.next.Count(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) Reverse(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "reverse",
+			"input", s,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Reverse(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) Trim(ctx context.Context, s string, cutset string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "trim",
+			"input", s,
+			"cutset", cutset,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Trim(ctx, s, cutset)
+	return
+}
+
+func (mw loggingMiddleware) Health(ctx context.Context) (healthy bool, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "health",
+			"healthy", healthy,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	healthy, err = mw.next.Health(ctx)
+	return
+}
+
+func (mw loggingMiddleware) UppercaseBatch(ctx context.Context, ss []string) (output []string, errs []error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, nil).Log(
+			"method", "uppercase_batch",
+			"size", len(ss),
+			"failed", countErrors(errs),
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, errs = mw.next.UppercaseBatch(ctx, ss)
+	return
+}
+
+func (mw loggingMiddleware) WordCount(ctx context.Context, s string) (n int, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "wordcount",
+			"input", s,
 			"n", n,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	n, err = mw.next.WordCount(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) RuneCount(ctx context.Context, s string) (n int, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "runecount",
+			"input", s,
+			"n", n,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	n, err = mw.next.RuneCount(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) Concat(ctx context.Context, ss []string, sep string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "concat",
+			"ss", ss,
+			"sep", sep,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Concat(ctx, ss, sep)
+	return
+}
+
+func (mw loggingMiddleware) Replace(ctx context.Context, s, old, new string, n int) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "replace",
+			"input", s,
+			"old", old,
+			"new", new,
+			"n", n,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Replace(ctx, s, old, new, n)
+	return
+}
+
+func (mw loggingMiddleware) Contains(ctx context.Context, s, substr string) (found bool, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "contains",
+			"input", s,
+			"substr", substr,
+			"found", found,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	found, err = mw.next.Contains(ctx, s, substr)
+	return
+}
+
+func (mw loggingMiddleware) Split(ctx context.Context, s, sep string) (parts []string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "split",
+			"input", s,
+			"sep", sep,
+			"parts", parts,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	parts, err = mw.next.Split(ctx, s, sep)
+	return
+}
+
+func (mw loggingMiddleware) Title(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "title",
+			"input", s,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Title(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) Version(ctx context.Context) (info BuildInfo, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "version",
+			"version", info.Version,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	info, err = mw.next.Version(ctx)
+	return
+}
+
+func (mw loggingMiddleware) Pad(ctx context.Context, s string, width int, pad string, left bool) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "pad",
+			"input", s,
+			"width", width,
+			"pad", pad,
+			"left", left,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Pad(ctx, s, width, pad, left)
+	return
+}
+
+func (mw loggingMiddleware) Capitalize(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "capitalize",
+			"input", s,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Capitalize(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) Base64Encode(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "base64_encode",
+			"input", s,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Base64Encode(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) Base64Decode(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "base64_decode",
+			"input", s,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Base64Decode(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) Hash(ctx context.Context, s, algo string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "hash",
+			"input", s,
+			"algo", algo,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Hash(ctx, s, algo)
+	return
+}
+
+func (mw loggingMiddleware) UppercaseExcept(ctx context.Context, s, except string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "uppercase_except",
+			"input", s,
+			"except", except,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.UppercaseExcept(ctx, s, except)
+	return
+}
+
+func (mw loggingMiddleware) CharFrequency(ctx context.Context, s string) (freq map[string]int, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "charfrequency",
+			"input", s,
+			"distinct", len(freq),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	freq, err = mw.next.CharFrequency(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) Distance(ctx context.Context, a, b string) (n int, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "distance",
+			"a", a,
+			"b", b,
+			"n", n,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	n, err = mw.next.Distance(ctx, a, b)
+	return
+}
+
+func (mw loggingMiddleware) Repeat(ctx context.Context, s string, count int) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "repeat",
+			"input", s,
+			"count", count,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Repeat(ctx, s, count)
+	return
+}
+
+func (mw loggingMiddleware) Truncate(ctx context.Context, s string, max int, ellipsis bool) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "truncate",
+			"input", s,
+			"max", max,
+			"ellipsis", ellipsis,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Truncate(ctx, s, max, ellipsis)
+	return
+}
+
+func (mw loggingMiddleware) IsPalindrome(ctx context.Context, s string, strip bool) (palindrome bool, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "palindrome",
+			"input", s,
+			"strip", strip,
+			"palindrome", palindrome,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	palindrome, err = mw.next.IsPalindrome(ctx, s, strip)
+	return
+}
+
+func (mw loggingMiddleware) Normalize(ctx context.Context, s, form string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "normalize",
+			"input", s,
+			"form", form,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Normalize(ctx, s, form)
+	return
+}
+
+func (mw loggingMiddleware) Soundex(ctx context.Context, s string) (code string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "soundex",
+			"input", s,
+			"code", code,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	code, err = mw.next.Soundex(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) CountSubstr(ctx context.Context, s, substr string) (n int, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "countsubstr",
+			"input", s,
+			"substr", substr,
+			"n", n,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	n, err = mw.next.CountSubstr(ctx, s, substr)
+	return
+}
+
+func (mw loggingMiddleware) ConvertCase(ctx context.Context, s, to string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "convertcase",
+			"input", s,
+			"to", to,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.ConvertCase(ctx, s, to)
+	return
+}
+
+func (mw loggingMiddleware) RegexFind(ctx context.Context, s, pattern string) (matches []string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "regex_find",
+			"input", s,
+			"pattern", pattern,
+			"matches", len(matches),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	matches, err = mw.next.RegexFind(ctx, s, pattern)
+	return
+}
+
+func (mw loggingMiddleware) CollapseSpaces(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "collapse",
+			"input", s,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.CollapseSpaces(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) DetectScript(ctx context.Context, s string) (script string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "detectscript",
+			"input", s,
+			"script", script,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	script, err = mw.next.DetectScript(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) URLEncode(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "url_encode",
+			"input", s,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.URLEncode(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) URLDecode(ctx context.Context, s string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "url_decode",
+			"input", s,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.URLDecode(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) Slugify(ctx context.Context, s string) (slug string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "slugify",
+			"input", s,
+			"slug", slug,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	slug, err = mw.next.Slugify(ctx, s)
+	return
+}
+
+// Mask leaves s out of the log, since it's what the caller wants hidden.
+func (mw loggingMiddleware) Mask(ctx context.Context, s string, visible int, mask string) (output string, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "mask",
+			"visible", visible,
+			"mask", mask,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Mask(ctx, s, visible, mask)
+	return
+}
+
+func (mw loggingMiddleware) LineCount(ctx context.Context, s string) (lines int, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "linecount",
+			"input", s,
+			"lines", lines,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	lines, err = mw.next.LineCount(ctx, s)
+	return
+}
+
+func (mw loggingMiddleware) UppercaseStream(ctx context.Context, dst io.Writer, src io.Reader) (n int64, err error) {
+	defer func(begin time.Time) {
+		_ = mw.leveled(ctx, err).Log(
+			"method", "uppercase_stream",
+			"bytes", n,
+			"err", err,
 			"took", time.Since(begin),
 		)
 	}(time.Now())
 
-	n = mw.next.Count(s)
+	n, err = mw.next.UppercaseStream(ctx, dst, src)
 	return
 }
@@ -1,60 +1,842 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/apache/thrift/lib/go/thrift"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
-	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/go-kit/kit/log/level"
+	consulsd "github.com/go-kit/kit/sd/consul"
+	kitzipkin "github.com/go-kit/kit/tracing/zipkin"
 	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/nats-io/go-nats"
+	"github.com/openzipkin/zipkin-go"
+	zipkinhttp "github.com/openzipkin/zipkin-go/reporter/http"
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+
+	"github.com/AndrewSC208/StringService/pb"
+	"github.com/AndrewSC208/StringService/thrift/gen-go/stringsvc"
+)
+
+// breakerTimeout is how long an open circuit breaker waits before letting
+// breakerMaxRequests trial calls through.
+const (
+	breakerTimeout     = 30 * time.Second
+	breakerMaxRequests = 1
+)
+
+// retryMax is how many times a call failing with a RetriableError is retried.
+// The first retry waits retryBackoff, and each one after that twice as long.
+const (
+	retryMax     = 2
+	retryBackoff = 50 * time.Millisecond
 )
 
 func main() {
+	start := time.Now()
 	logger := log.NewLogfmtLogger(os.Stderr)
 
+	configFile := flag.String("config", "", "path to a YAML or JSON config file")
+	flag.Parse()
+
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		level.Error(logger).Log("during", "loadConfig", "err", err)
+		os.Exit(1)
+	}
+	logOutput := newLogWriter(cfg)
+	if logger, err = newLogger(cfg.LogFormat, logOutput); err != nil {
+		level.Error(log.NewLogfmtLogger(os.Stderr)).Log("during", "newLogger", "err", err)
+		os.Exit(1)
+	}
+	lvl, err := levelFilter(cfg.LogLevel)
+	if err != nil {
+		level.Error(logger).Log("during", "levelFilter", "err", err)
+		os.Exit(1)
+	}
+	logger = level.NewFilter(logger, lvl)
+	level.Info(logger).Log("msg", "build", "version", version, "git_commit", gitCommit, "build_time", buildTime)
+	level.Info(logger).Log(
+		"msg", "config",
+		"http_addr", cfg.HTTPAddr,
+		"grpc_addr", cfg.GRPCAddr,
+		"thrift_addr", cfg.ThriftAddr,
+		"metrics_namespace", cfg.MetricsNamespace,
+		"metrics_subsystem", cfg.MetricsSubsystem,
+		"metrics_latency_buckets", fmt.Sprint(cfg.MetricsLatencyBuckets),
+		"metrics_input_size_buckets", fmt.Sprint(cfg.MetricsInputSizeBuckets),
+		"metrics_auth", cfg.MetricsUser != "",
+		"log_level", cfg.LogLevel,
+		"log_format", cfg.LogFormat,
+		"log_stderr", cfg.LogStderr,
+		"log_file", cfg.LogFile,
+		"access_log", cfg.AccessLog,
+		"server_timing", cfg.ServerTiming,
+		"otlp_endpoint", cfg.OTLPEndpoint,
+		"zipkin_url", cfg.ZipkinURL,
+		"nats_url", cfg.NATSURL,
+		"amqp_url", redactURL(cfg.AMQPURL),
+		"rate_limit", cfg.RateLimit,
+		"rate_burst", cfg.RateBurst,
+		"client_rate_limit", cfg.ClientRateLimit,
+		"client_rate_burst", cfg.ClientRateBurst,
+		"max_batch_size", cfg.MaxBatchSize,
+		"max_input_length", cfg.MaxInputLength,
+		"max_output_length", cfg.MaxOutputLength,
+		"max_body_bytes", cfg.MaxBodyBytes,
+		"max_header_bytes", cfg.MaxHeaderBytes,
+		"stream_max_bytes", cfg.StreamMaxBytes,
+		"stream_timeout", cfg.StreamTimeout,
+		"max_concurrent", cfg.MaxConcurrent,
+		"max_concurrent_per_method", cfg.MaxConcurrentPerMethod,
+		"method_concurrency", fmt.Sprint(cfg.MethodConcurrency),
+		"cache_size", cfg.CacheSize,
+		"cache_max_input", cfg.CacheMaxInput,
+		"enable_pprof", cfg.EnablePprof,
+		"request_timeout", cfg.RequestTimeout,
+		"shutdown_timeout", cfg.ShutdownTimeout,
+		"drain_delay", cfg.DrainDelay,
+		"idempotency_ttl", cfg.IdempotencyTTL,
+		"idempotency_max_keys", cfg.IdempotencyMaxKeys,
+		"cors_origins", strings.Join(cfg.CORSOrigins, ","),
+		"api_keys", len(cfg.APIKeys),
+		"tls_cert", cfg.TLSCert,
+		"consul_addr", cfg.ConsulAddr,
+	)
+
+	registry := newRegistry()
 	fieldKeys := []string{"method", "error"}
-	requestCount := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
-		Namespace: "my_group",
-		Subsystem: "string_service",
+	requestCount := newCounter(registry, stdprometheus.CounterOpts{
+		Namespace: cfg.MetricsNamespace,
+		Subsystem: cfg.MetricsSubsystem,
 		Name:      "request_count",
 		Help:      "Number of requests received.",
 	}, fieldKeys)
-	requestLatency := kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
-		Namespace: "my_group",
-		Subsystem: "string_service",
-		Name:      "request_latency_microseconds",
-		Help:      "Total duration of requests in microseconds.",
+	requestLatency := newHistogram(registry, stdprometheus.HistogramOpts{
+		Namespace: cfg.MetricsNamespace,
+		Subsystem: cfg.MetricsSubsystem,
+		Name:      "request_latency_seconds",
+		Help:      "Total duration of requests in seconds.",
+		Buckets:   cfg.MetricsLatencyBuckets,
 	}, fieldKeys)
-	countResult := kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
-		Namespace: "my_group",
-		Subsystem: "string_service",
+	countResult := newSummary(registry, stdprometheus.SummaryOpts{
+		Namespace: cfg.MetricsNamespace,
+		Subsystem: cfg.MetricsSubsystem,
 		Name:      "count_result",
-		Help:      "The result of each count method.",
-	}, []string{}) // no fields here
+		Help:      "The result of each method returning an integer.",
+	}, fieldKeys)
+	batchSize := newSummary(registry, stdprometheus.SummaryOpts{
+		Namespace: cfg.MetricsNamespace,
+		Subsystem: cfg.MetricsSubsystem,
+		Name:      "batch_size",
+		Help:      "The number of strings in each batch request.",
+	}, []string{})
+	inputSize := newHistogram(registry, stdprometheus.HistogramOpts{
+		Namespace: cfg.MetricsNamespace,
+		Subsystem: cfg.MetricsSubsystem,
+		Name:      "input_size_bytes",
+		Help:      "The length of each input string in bytes.",
+		Buckets:   cfg.MetricsInputSizeBuckets,
+	}, []string{"method"})
+	cacheHits := newCounter(registry, stdprometheus.CounterOpts{
+		Namespace: cfg.MetricsNamespace,
+		Subsystem: cfg.MetricsSubsystem,
+		Name:      "cache_hits_total",
+		Help:      "Number of results served from the cache.",
+	}, []string{"method"})
+	panics := newCounter(registry, stdprometheus.CounterOpts{
+		Namespace: cfg.MetricsNamespace,
+		Subsystem: cfg.MetricsSubsystem,
+		Name:      "panics_total",
+		Help:      "Number of panics recovered from, by method.",
+	}, []string{"method"})
+	inflight := newGauge(registry, stdprometheus.GaugeOpts{
+		Namespace: cfg.MetricsNamespace,
+		Subsystem: cfg.MetricsSubsystem,
+		Name:      "requests_in_flight",
+		Help:      "Number of calls currently being handled.",
+	}, []string{})
+	decodeErrors := newCounter(registry, stdprometheus.CounterOpts{
+		Namespace: cfg.MetricsNamespace,
+		Subsystem: cfg.MetricsSubsystem,
+		Name:      "decode_errors_total",
+		Help:      "Number of HTTP requests that could not be decoded, by path.",
+	}, []string{"path"})
+	methodInflight := newGauge(registry, stdprometheus.GaugeOpts{
+		Namespace: cfg.MetricsNamespace,
+		Subsystem: cfg.MetricsSubsystem,
+		Name:      "method_requests_in_flight",
+		Help:      "Number of calls to each method currently being handled.",
+	}, []string{"method"})
+	errorCount := newCounter(registry, stdprometheus.CounterOpts{
+		Namespace: cfg.MetricsNamespace,
+		Subsystem: cfg.MetricsSubsystem,
+		Name:      "errors_total",
+		Help:      "Number of failed calls by method and error type.",
+	}, []string{"method", "error_type"})
+	registry.MustRegister(stdprometheus.NewGaugeFunc(stdprometheus.GaugeOpts{
+		Namespace: cfg.MetricsNamespace,
+		Subsystem: cfg.MetricsSubsystem,
+		Name:      "uptime_seconds",
+		Help:      "Time since the service started, in seconds.",
+	}, func() float64 { return time.Since(start).Seconds() }))
+
+	// Spans are only exported when a collector is configured; otherwise the
+	// global no-op tracer provider is used.
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if cfg.OTLPEndpoint != "" {
+		exporter, err := otlptracehttp.New(
+			context.Background(),
+			otlptracehttp.WithEndpoint(cfg.OTLPEndpoint),
+			otlptracehttp.WithInsecure(),
+		)
+		if err != nil {
+			level.Error(logger).Log("during", "otlptracehttp.New", "err", err)
+			os.Exit(1)
+		}
+		tp := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "stringsvc"))),
+		)
+		defer tp.Shutdown(context.Background())
+		otel.SetTracerProvider(tp)
+	}
+	tracer := otel.Tracer("stringsvc")
+
+	// Zipkin can be used alongside or instead of OpenTelemetry. Endpoints
+	// and handlers are only traced with it when a collector is configured.
+	var zipkinTracer *zipkin.Tracer
+	if cfg.ZipkinURL != "" {
+		zipkinReporter := zipkinhttp.NewReporter(cfg.ZipkinURL)
+		defer zipkinReporter.Close()
+		localEndpoint, err := zipkin.NewEndpoint("stringsvc", "")
+		if err != nil {
+			level.Error(logger).Log("during", "zipkin.NewEndpoint", "err", err)
+			os.Exit(1)
+		}
+		zipkinTracer, err = zipkin.NewTracer(zipkinReporter, zipkin.WithLocalEndpoint(localEndpoint))
+		if err != nil {
+			level.Error(logger).Log("during", "zipkin.NewTracer", "err", err)
+			os.Exit(1)
+		}
+	}
+
+	// Middlewares are listed from the innermost to the outermost.
+	mws := []Middleware{newValidatingMiddleware(cfg.MaxInputLength, cfg.MaxOutputLength)}
+	if cfg.CacheSize > 0 {
+		caching, err := newCachingMiddleware(cfg.CacheSize, cfg.CacheMaxInput, cacheHits)
+		if err != nil {
+			level.Error(logger).Log("during", "newCachingMiddleware", "err", err)
+			os.Exit(1)
+		}
+		mws = append(mws, caching)
+	}
+	var draining atomic.Bool
+	var clients *clientLimiters
+	if cfg.ClientRateLimit > 0 {
+		clients = newClientLimiters(cfg.ClientRateLimit, cfg.ClientRateBurst, len(cfg.APIKeys) > 0)
+	}
+	mws = append(mws,
+		newLoggingMiddleware(logger),
+		newRateLimitingMiddleware(cfg.RateLimit, cfg.RateBurst, clients),
+		newInstrumentingMiddleware(requestCount, requestLatency, countResult, batchSize, inputSize, errorCount),
+		newDrainingMiddleware(&draining),
+	)
+	svc := decorate(stringService{}, mws...)
 
-	var svc StringService
-	svc = stringService{}
-	svc = loggingMiddleware{logger, svc}
-	svc = instrumentingMiddleware{requestCount, requestLatency, countResult, svc}
+	// The concurrency limit is shared by all endpoints. It sits outside the
+	// circuit breakers so that shedding load doesn't open them.
+	limitConcurrency := concurrencyLimitingMiddleware(cfg.MaxConcurrent, inflight)
+	idempotency, err := newIdempotencyStore(cfg.IdempotencyTTL, cfg.IdempotencyMaxKeys)
+	if err != nil {
+		level.Error(logger).Log("during", "newIdempotencyStore", "err", err)
+		os.Exit(1)
+	}
+	wrap := func(method string) endpoint.Middleware {
+		mws := []endpoint.Middleware{
+			authMiddleware(cfg.APIKeys),
+			requestValidationMiddleware,
+			idempotencyMiddleware(idempotency, method),
+		}
+		// The per-method bulkhead comes first so that calls to a saturated
+		// method are turned away without taking a global slot.
+		if max := cfg.methodConcurrency(method); max > 0 {
+			mws = append(mws, concurrencyLimitingMiddleware(max, methodInflight.With("method", method)))
+		}
+		mws = append(mws,
+			limitConcurrency,
+			retryMiddleware(retryMax, retryBackoff),
+			circuitBreakingMiddleware(method, breakerTimeout, breakerMaxRequests),
+		)
+		mw := endpoint.Chain(recoveryMiddleware(logger, panics, method), mws...)
+		if zipkinTracer != nil {
+			mw = endpoint.Chain(zipkinTracingMiddleware(zipkinTracer, method), mw)
+		}
+		return mw
+	}
+	uppercaseEndpoint := wrap("uppercase")(makeUppercaseEndpoint(svc))
+	uppercaseEndpoint = tracingMiddleware(tracer, "uppercase")(uppercaseEndpoint)
+	lowercaseEndpoint := wrap("lowercase")(makeLowercaseEndpoint(svc))
+	countEndpoint := wrap("count")(makeCountEndpoint(svc))
+	countEndpoint = tracingMiddleware(tracer, "count")(countEndpoint)
+	reverseEndpoint := wrap("reverse")(makeReverseEndpoint(svc))
+	trimEndpoint := wrap("trim")(makeTrimEndpoint(svc))
+	uppercaseBatchEndpoint := wrap("uppercase_batch")(makeUppercaseBatchEndpoint(svc))
+	wordCountEndpoint := wrap("wordcount")(makeWordCountEndpoint(svc))
+	runeCountEndpoint := wrap("runecount")(makeRuneCountEndpoint(svc))
+	concatEndpoint := wrap("concat")(makeConcatEndpoint(svc))
+	replaceEndpoint := wrap("replace")(makeReplaceEndpoint(svc))
+	containsEndpoint := wrap("contains")(makeContainsEndpoint(svc))
+	splitEndpoint := wrap("split")(makeSplitEndpoint(svc))
+	titleEndpoint := wrap("title")(makeTitleEndpoint(svc))
+	padEndpoint := wrap("pad")(makePadEndpoint(svc))
+	capitalizeEndpoint := wrap("capitalize")(makeCapitalizeEndpoint(svc))
+	base64EncodeEndpoint := wrap("base64_encode")(makeBase64EncodeEndpoint(svc))
+	base64DecodeEndpoint := wrap("base64_decode")(makeBase64DecodeEndpoint(svc))
+	hashEndpoint := wrap("hash")(makeHashEndpoint(svc))
+	uppercaseExceptEndpoint := wrap("uppercase_except")(makeUppercaseExceptEndpoint(svc))
+	charFrequencyEndpoint := wrap("charfrequency")(makeCharFrequencyEndpoint(svc))
+	distanceEndpoint := wrap("distance")(makeDistanceEndpoint(svc))
+	repeatEndpoint := wrap("repeat")(makeRepeatEndpoint(svc))
+	truncateEndpoint := wrap("truncate")(makeTruncateEndpoint(svc))
+	isPalindromeEndpoint := wrap("palindrome")(makeIsPalindromeEndpoint(svc))
+	normalizeEndpoint := wrap("normalize")(makeNormalizeEndpoint(svc))
+	soundexEndpoint := wrap("soundex")(makeSoundexEndpoint(svc))
+	countSubstrEndpoint := wrap("countsubstr")(makeCountSubstrEndpoint(svc))
+	convertCaseEndpoint := wrap("convertcase")(makeConvertCaseEndpoint(svc))
+	regexFindEndpoint := wrap("regex_find")(makeRegexFindEndpoint(svc))
+	collapseSpacesEndpoint := wrap("collapse")(makeCollapseSpacesEndpoint(svc))
+	detectScriptEndpoint := wrap("detectscript")(makeDetectScriptEndpoint(svc))
+	urlEncodeEndpoint := wrap("url_encode")(makeURLEncodeEndpoint(svc))
+	urlDecodeEndpoint := wrap("url_decode")(makeURLDecodeEndpoint(svc))
+	slugifyEndpoint := wrap("slugify")(makeSlugifyEndpoint(svc))
+	maskEndpoint := wrap("mask")(makeMaskEndpoint(svc))
+	lineCountEndpoint := wrap("linecount")(makeLineCountEndpoint(svc))
+
+	// decode counts and logs the errors of an HTTP request decoder, which are
+	// caused by malformed requests rather than by the service.
+	decode := func(dec httptransport.DecodeRequestFunc) httptransport.DecodeRequestFunc {
+		return countDecodeErrors(dec, decodeErrors, logger)
+	}
+	encode := httptransport.EncodeResponseFunc(encodeResponse)
+	errorEncoder := httptransport.ErrorEncoder(encodeError)
+	var before []httptransport.RequestFunc
+	if cfg.ServerTiming {
+		// Reports decode, endpoint and encode durations in a Server-Timing
+		// header. Off by default, since it buffers every response.
+		countDecode := decode
+		decode = func(dec httptransport.DecodeRequestFunc) httptransport.DecodeRequestFunc {
+			return timeDecode(countDecode(dec))
+		}
+		encode = timeEncode(encode)
+		errorEncoder = timeErrors(errorEncoder)
+		before = append(before, startServerTiming)
+	}
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(errorEncoder),
+		httptransport.ServerBefore(before...),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+			populateRequestID,
+			extractTraceContext,
+			populateAPIKey,
+			populateDeadline(cfg.RequestTimeout),
+			populateLanguage,
+			populateIdempotencyKey,
+		),
+		httptransport.ServerAfter(echoRequestID),
+		httptransport.ServerFinalizer(cancelDeadline),
+	}
+	if zipkinTracer != nil {
+		// Continues traces propagated in B3 headers.
+		options = append(options, kitzipkin.HTTPServerTrace(zipkinTracer))
+	}
 
 	uppercaseHandler := httptransport.NewServer(
-		makeUppercaseEndpoint(svc),
-		decodeUppercaseRequest,
-		encodeResponse,
+		uppercaseEndpoint,
+		decode(decodeUppercaseRequest),
+		encode,
+		options...,
+	)
+
+	lowercaseHandler := httptransport.NewServer(
+		lowercaseEndpoint,
+		decode(decodeLowercaseRequest),
+		encode,
+		options...,
 	)
 
 	countHandler := httptransport.NewServer(
-		makeCountEndpoint(svc),
-		decodeCountRequest,
-		encodeResponse,
+		countEndpoint,
+		decode(decodeCountRequest),
+		encode,
+		options...,
+	)
+
+	reverseHandler := httptransport.NewServer(
+		reverseEndpoint,
+		decode(decodeReverseRequest),
+		encode,
+		options...,
+	)
+
+	trimHandler := httptransport.NewServer(
+		trimEndpoint,
+		decode(decodeTrimRequest),
+		encode,
+		options...,
+	)
+
+	healthHandler := httptransport.NewServer(
+		makeHealthEndpoint(svc),
+		decode(decodeHealthRequest),
+		encodeHealthResponse,
+		options...,
+	)
+
+	versionHandler := httptransport.NewServer(
+		makeVersionEndpoint(svc),
+		decode(decodeVersionRequest),
+		encode,
+		options...,
+	)
+
+	uppercaseBatchHandler := httptransport.NewServer(
+		uppercaseBatchEndpoint,
+		decode(decodeUppercaseBatchRequest(cfg.MaxBatchSize)),
+		encode,
+		options...,
+	)
+
+	wordCountHandler := httptransport.NewServer(
+		wordCountEndpoint,
+		decode(decodeWordCountRequest),
+		encode,
+		options...,
+	)
+
+	runeCountHandler := httptransport.NewServer(
+		runeCountEndpoint,
+		decode(decodeRuneCountRequest),
+		encode,
+		options...,
+	)
+
+	concatHandler := httptransport.NewServer(
+		concatEndpoint,
+		decode(decodeConcatRequest),
+		encode,
+		options...,
+	)
+
+	replaceHandler := httptransport.NewServer(
+		replaceEndpoint,
+		decode(decodeReplaceRequest),
+		encode,
+		options...,
+	)
+
+	containsHandler := httptransport.NewServer(
+		containsEndpoint,
+		decode(decodeContainsRequest),
+		encode,
+		options...,
+	)
+
+	splitHandler := httptransport.NewServer(
+		splitEndpoint,
+		decode(decodeSplitRequest),
+		encode,
+		options...,
+	)
+
+	titleHandler := httptransport.NewServer(
+		titleEndpoint,
+		decode(decodeTitleRequest),
+		encode,
+		options...,
+	)
+
+	padHandler := httptransport.NewServer(
+		padEndpoint,
+		decode(decodePadRequest),
+		encode,
+		options...,
+	)
+
+	capitalizeHandler := httptransport.NewServer(
+		capitalizeEndpoint,
+		decode(decodeCapitalizeRequest),
+		encode,
+		options...,
+	)
+
+	base64EncodeHandler := httptransport.NewServer(
+		base64EncodeEndpoint,
+		decode(decodeBase64EncodeRequest),
+		encode,
+		options...,
+	)
+
+	base64DecodeHandler := httptransport.NewServer(
+		base64DecodeEndpoint,
+		decode(decodeBase64DecodeRequest),
+		encode,
+		options...,
+	)
+
+	hashHandler := httptransport.NewServer(
+		hashEndpoint,
+		decode(decodeHashRequest),
+		encode,
+		options...,
 	)
 
-	http.Handle("/uppercase", uppercaseHandler)
-	http.Handle("/count", countHandler)
-	http.Handle("/metrics", promhttp.Handler())
-	logger.Log("msg", "HTTP", "addr", ":8080")
-	logger.Log("err", http.ListenAndServe(":8080", nil))
+	uppercaseExceptHandler := httptransport.NewServer(
+		uppercaseExceptEndpoint,
+		decode(decodeUppercaseExceptRequest),
+		encode,
+		options...,
+	)
+
+	charFrequencyHandler := httptransport.NewServer(
+		charFrequencyEndpoint,
+		decode(decodeCharFrequencyRequest),
+		encode,
+		options...,
+	)
+
+	distanceHandler := httptransport.NewServer(
+		distanceEndpoint,
+		decode(decodeDistanceRequest),
+		encode,
+		options...,
+	)
+
+	repeatHandler := httptransport.NewServer(
+		repeatEndpoint,
+		decode(decodeRepeatRequest),
+		encode,
+		options...,
+	)
+
+	truncateHandler := httptransport.NewServer(
+		truncateEndpoint,
+		decode(decodeTruncateRequest),
+		encode,
+		options...,
+	)
+
+	isPalindromeHandler := httptransport.NewServer(
+		isPalindromeEndpoint,
+		decode(decodeIsPalindromeRequest),
+		encode,
+		options...,
+	)
+
+	normalizeHandler := httptransport.NewServer(
+		normalizeEndpoint,
+		decode(decodeNormalizeRequest),
+		encode,
+		options...,
+	)
+
+	soundexHandler := httptransport.NewServer(
+		soundexEndpoint,
+		decode(decodeSoundexRequest),
+		encode,
+		options...,
+	)
+
+	countSubstrHandler := httptransport.NewServer(
+		countSubstrEndpoint,
+		decode(decodeCountSubstrRequest),
+		encode,
+		options...,
+	)
+
+	convertCaseHandler := httptransport.NewServer(
+		convertCaseEndpoint,
+		decode(decodeConvertCaseRequest),
+		encode,
+		options...,
+	)
+
+	regexFindHandler := httptransport.NewServer(
+		regexFindEndpoint,
+		decode(decodeRegexFindRequest),
+		encode,
+		options...,
+	)
+
+	collapseSpacesHandler := httptransport.NewServer(
+		collapseSpacesEndpoint,
+		decode(decodeCollapseSpacesRequest),
+		encode,
+		options...,
+	)
+
+	detectScriptHandler := httptransport.NewServer(
+		detectScriptEndpoint,
+		decode(decodeDetectScriptRequest),
+		encode,
+		options...,
+	)
+
+	urlEncodeHandler := httptransport.NewServer(
+		urlEncodeEndpoint,
+		decode(decodeURLEncodeRequest),
+		encode,
+		options...,
+	)
+
+	urlDecodeHandler := httptransport.NewServer(
+		urlDecodeEndpoint,
+		decode(decodeURLDecodeRequest),
+		encode,
+		options...,
+	)
+
+	slugifyHandler := httptransport.NewServer(
+		slugifyEndpoint,
+		decode(decodeSlugifyRequest),
+		encode,
+		options...,
+	)
+
+	maskHandler := httptransport.NewServer(
+		maskEndpoint,
+		decode(decodeMaskRequest),
+		encode,
+		options...,
+	)
+
+	lineCountHandler := httptransport.NewServer(
+		lineCountEndpoint,
+		decode(decodeLineCountRequest),
+		encode,
+		options...,
+	)
+
+	// Each route only accepts the methods in its pattern; others get a 405
+	// with an Allow header.
+	mux := http.NewServeMux()
+	mux.Handle("POST /uppercase", uppercaseHandler)
+	mux.Handle("POST /lowercase", lowercaseHandler)
+	mux.Handle("GET /count", countHandler)
+	mux.Handle("POST /count", countHandler)
+	mux.Handle("POST /reverse", reverseHandler)
+	mux.Handle("POST /trim", trimHandler)
+	mux.Handle("POST /uppercase/batch", uppercaseBatchHandler)
+	mux.Handle("POST /wordcount", wordCountHandler)
+	mux.Handle("POST /runecount", runeCountHandler)
+	mux.Handle("POST /concat", concatHandler)
+	mux.Handle("POST /replace", replaceHandler)
+	mux.Handle("POST /contains", containsHandler)
+	mux.Handle("POST /split", splitHandler)
+	mux.Handle("POST /title", titleHandler)
+	mux.Handle("POST /pad", padHandler)
+	mux.Handle("POST /capitalize", capitalizeHandler)
+	mux.Handle("POST /base64/encode", base64EncodeHandler)
+	mux.Handle("POST /base64/decode", base64DecodeHandler)
+	mux.Handle("POST /hash", hashHandler)
+	mux.Handle("POST /uppercase/except", uppercaseExceptHandler)
+	mux.Handle("POST /frequency", charFrequencyHandler)
+	mux.Handle("POST /distance", distanceHandler)
+	mux.Handle("POST /repeat", repeatHandler)
+	mux.Handle("POST /truncate", truncateHandler)
+	mux.Handle("POST /palindrome", isPalindromeHandler)
+	mux.Handle("POST /normalize", normalizeHandler)
+	mux.Handle("POST /soundex", soundexHandler)
+	mux.Handle("POST /countsubstr", countSubstrHandler)
+	mux.Handle("POST /convertcase", convertCaseHandler)
+	mux.Handle("POST /regex/find", regexFindHandler)
+	mux.Handle("POST /collapse", collapseSpacesHandler)
+	mux.Handle("POST /detectscript", detectScriptHandler)
+	mux.Handle("POST /url/encode", urlEncodeHandler)
+	mux.Handle("POST /url/decode", urlDecodeHandler)
+	mux.Handle("POST /slugify", slugifyHandler)
+	mux.Handle("POST /mask", maskHandler)
+	mux.Handle("POST /linecount", lineCountHandler)
+	mux.Handle("GET /health", healthHandler)
+	mux.Handle("GET /version", versionHandler)
+	mux.Handle("GET /stats", basicAuthHandler(statsHandler(registry, cfg.MetricsNamespace, cfg.MetricsSubsystem), cfg.MetricsUser, cfg.MetricsPass))
+	mux.HandleFunc("GET /openapi.json", serveOpenAPISpec)
+	mux.HandleFunc("GET /docs", serveDocs)
+	mux.HandleFunc("GET /docs/docs.js", serveDocsScript)
+	mux.HandleFunc("GET /docs/docs.css", serveDocsStyle)
+	mux.Handle("GET /metrics", basicAuthHandler(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), cfg.MetricsUser, cfg.MetricsPass))
+	if cfg.EnablePprof {
+		// CPU profiles and traces are cut short by the request timeout, so
+		// ask for ?seconds= less than it.
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	if cfg.NATSURL != "" {
+		nc, err := nats.Connect(cfg.NATSURL)
+		if err != nil {
+			level.Error(logger).Log("transport", "NATS", "during", "Connect", "err", err)
+			os.Exit(1)
+		}
+		defer nc.Close()
+		if _, err := subscribeNATS(nc, uppercaseEndpoint, countEndpoint, logger); err != nil {
+			level.Error(logger).Log("transport", "NATS", "during", "Subscribe", "err", err)
+			os.Exit(1)
+		}
+		level.Info(logger).Log("msg", "NATS", "addr", cfg.NATSURL)
+	}
+
+	if cfg.AMQPURL != "" {
+		conn, err := amqp.Dial(cfg.AMQPURL)
+		if err != nil {
+			level.Error(logger).Log("transport", "AMQP", "during", "Dial", "err", err)
+			os.Exit(1)
+		}
+		defer conn.Close()
+		ch, err := conn.Channel()
+		if err != nil {
+			level.Error(logger).Log("transport", "AMQP", "during", "Channel", "err", err)
+			os.Exit(1)
+		}
+		if err := consumeAMQP(ch, uppercaseEndpoint, countEndpoint, logger); err != nil {
+			level.Error(logger).Log("transport", "AMQP", "during", "Consume", "err", err)
+			os.Exit(1)
+		}
+		level.Info(logger).Log("msg", "AMQP", "addr", redactURL(cfg.AMQPURL))
+	}
+
+	grpcListener, err := net.Listen("tcp", cfg.GRPCAddr)
+	if err != nil {
+		level.Error(logger).Log("transport", "gRPC", "during", "Listen", "err", err)
+		os.Exit(1)
+	}
+	grpcServer := grpc.NewServer()
+	pb.RegisterStringServiceServer(grpcServer, newGRPCServer(uppercaseEndpoint, countEndpoint))
+
+	thriftSocket, err := thrift.NewTServerSocket(cfg.ThriftAddr)
+	if err != nil {
+		level.Error(logger).Log("transport", "Thrift", "during", "Listen", "err", err)
+		os.Exit(1)
+	}
+	thriftServer := thrift.NewTSimpleServer4(
+		stringsvc.NewStringServiceProcessor(newThriftServer(uppercaseEndpoint, countEndpoint)),
+		thriftSocket,
+		thrift.NewTTransportFactory(),
+		thrift.NewTBinaryProtocolFactoryDefault(),
+	)
+
+	// The profiling handlers register themselves on http.DefaultServeMux, so
+	// it is not used; they are only served when enabled.
+	var handler http.Handler = mux
+	handler = maxBodyHandler(handler, int64(cfg.MaxBodyBytes))
+	handler = timeoutHandler(handler, cfg.RequestTimeout)
+	// http.TimeoutHandler buffers the whole response, which would defeat the
+	// point of streaming, so the stream handler is routed around it and
+	// enforces its own body limit and deadline.
+	root := http.NewServeMux()
+	root.Handle("/", handler)
+	root.Handle("/uppercase/stream", uppercaseStreamHandler(
+		svc, cfg.APIKeys, int64(cfg.StreamMaxBytes), cfg.StreamTimeout, logger,
+		httptransport.PopulateRequestContext,
+		populateRequestID,
+		populateAPIKey,
+	))
+	handler = root
+	handler = corsHandler(handler, cfg.CORSOrigins)
+	if cfg.AccessLog {
+		handler = accessLogHandler(handler, os.Stdout)
+	}
+
+	httpServer := &http.Server{
+		Addr:           cfg.HTTPAddr,
+		Handler:        handler,
+		ReadTimeout:    cfg.RequestTimeout,
+		MaxHeaderBytes: cfg.MaxHeaderBytes,
+		TLSConfig:      tlsConfig(),
+	}
+
+	errs := make(chan error, 4)
+	go func() {
+		level.Info(logger).Log("msg", "gRPC", "addr", cfg.GRPCAddr)
+		errs <- grpcServer.Serve(grpcListener)
+	}()
+	go func() {
+		level.Info(logger).Log("msg", "Thrift", "addr", cfg.ThriftAddr)
+		errs <- thriftServer.Serve()
+	}()
+	go func() {
+		if cfg.TLSCert != "" {
+			level.Info(logger).Log("msg", "HTTPS", "addr", cfg.HTTPAddr)
+			errs <- httpServer.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+			return
+		}
+		level.Info(logger).Log("msg", "HTTP", "addr", cfg.HTTPAddr)
+		errs <- httpServer.ListenAndServe()
+	}()
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		errs <- fmt.Errorf("received signal %s", <-c)
+	}()
+
+	var registrar *consulsd.Registrar
+	if cfg.ConsulAddr != "" {
+		if registrar, err = newConsulRegistrar(cfg, logger); err != nil {
+			level.Error(logger).Log("during", "newConsulRegistrar", "err", err)
+			os.Exit(1)
+		}
+		registrar.Register()
+	}
+
+	level.Info(logger).Log("msg", "shutting down", "reason", <-errs)
+
+	// Leave Consul first so that no new traffic is routed here while we drain.
+	if registrar != nil {
+		registrar.Deregister()
+	}
+
+	// Fail health checks for DrainDelay, while still serving, so that load
+	// balancers have time to notice and stop sending new requests.
+	draining.Store(true)
+	level.Info(logger).Log("msg", "draining", "delay", cfg.DrainDelay, "timeout", cfg.ShutdownTimeout)
+	time.Sleep(cfg.DrainDelay)
+
+	// Give in-flight requests a chance to complete before exiting.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		level.Error(logger).Log("transport", "HTTP", "during", "Shutdown", "err", err)
+	}
+	grpcServer.GracefulStop()
+	level.Info(logger).Log("msg", "draining complete")
+	thriftServer.Stop()
+	idempotency.close()
+	level.Info(logger).Log("msg", "shutdown complete")
+	logOutput.Close()
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+// The kitprometheus New*From constructors register with the global default
+// registry, which panics if two instances of the service share a process.
+// These register with reg instead.
+
+func newCounter(reg stdprometheus.Registerer, opts stdprometheus.CounterOpts, labelNames []string) *kitprometheus.Counter {
+	cv := stdprometheus.NewCounterVec(opts, labelNames)
+	reg.MustRegister(cv)
+	return kitprometheus.NewCounter(cv)
+}
+
+func newGauge(reg stdprometheus.Registerer, opts stdprometheus.GaugeOpts, labelNames []string) *kitprometheus.Gauge {
+	gv := stdprometheus.NewGaugeVec(opts, labelNames)
+	reg.MustRegister(gv)
+	return kitprometheus.NewGauge(gv)
+}
+
+func newHistogram(reg stdprometheus.Registerer, opts stdprometheus.HistogramOpts, labelNames []string) *kitprometheus.Histogram {
+	hv := stdprometheus.NewHistogramVec(opts, labelNames)
+	reg.MustRegister(hv)
+	return kitprometheus.NewHistogram(hv)
+}
+
+func newSummary(reg stdprometheus.Registerer, opts stdprometheus.SummaryOpts, labelNames []string) *kitprometheus.Summary {
+	sv := stdprometheus.NewSummaryVec(opts, labelNames)
+	reg.MustRegister(sv)
+	return kitprometheus.NewSummary(sv)
+}
+
+// newRegistry returns a registry holding the Go runtime and process
+// collectors that the default registry would have provided.
+func newRegistry() *stdprometheus.Registry {
+	reg := stdprometheus.NewRegistry()
+	reg.MustRegister(
+		stdprometheus.NewGoCollector(),
+		stdprometheus.NewProcessCollector(stdprometheus.ProcessCollectorOpts{}),
+	)
+	return reg
+}
@@ -0,0 +1,13 @@
+package main
+
+// Middleware decorates a StringService with extra behaviour.
+type Middleware func(StringService) StringService
+
+// decorate wraps svc in mws in order, so the first middleware is the
+// innermost and sees each call last.
+func decorate(svc StringService, mws ...Middleware) StringService {
+	for _, mw := range mws {
+		svc = mw(svc)
+	}
+	return svc
+}
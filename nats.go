@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	natstransport "github.com/go-kit/kit/transport/nats"
+	"github.com/nats-io/go-nats"
+)
+
+// Subjects served by the NATS transport.
+const (
+	natsUppercaseSubject = "stringsvc.uppercase"
+	natsCountSubject     = "stringsvc.count"
+)
+
+// subscribeNATS serves the Uppercase and Count endpoints on their NATS
+// subjects. Request and response bodies are JSON in the same shape as the
+// HTTP transport, and replies are published to the message's reply subject.
+// NATS messages carry no headers, so these calls are rejected when API keys
+// are configured.
+func subscribeNATS(nc *nats.Conn, uppercase, count endpoint.Endpoint, logger log.Logger) ([]*nats.Subscription, error) {
+	options := []natstransport.SubscriberOption{
+		natstransport.SubscriberErrorEncoder(encodeNATSError),
+		natstransport.SubscriberErrorLogger(logger),
+	}
+
+	uppercaseSubscriber := natstransport.NewSubscriber(
+		uppercase,
+		decodeNATSUppercaseRequest,
+		natstransport.EncodeJSONResponse,
+		options...,
+	)
+	countSubscriber := natstransport.NewSubscriber(
+		count,
+		decodeNATSCountRequest,
+		natstransport.EncodeJSONResponse,
+		options...,
+	)
+
+	var subs []*nats.Subscription
+	for subject, subscriber := range map[string]*natstransport.Subscriber{
+		natsUppercaseSubject: uppercaseSubscriber,
+		natsCountSubject:     countSubscriber,
+	} {
+		sub, err := nc.Subscribe(subject, subscriber.ServeMsg(nc))
+		if err != nil {
+			for _, s := range subs {
+				s.Unsubscribe()
+			}
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+func decodeNATSUppercaseRequest(_ context.Context, msg *nats.Msg) (interface{}, error) {
+	var request uppercaseRequest
+	if err := json.Unmarshal(msg.Data, &request); err != nil {
+		return nil, ErrBadRequest
+	}
+	return request, nil
+}
+
+func decodeNATSCountRequest(_ context.Context, msg *nats.Msg) (interface{}, error) {
+	var request countRequest
+	if err := json.Unmarshal(msg.Data, &request); err != nil {
+		return nil, ErrBadRequest
+	}
+	return request, nil
+}
+
+// encodeNATSError publishes the error in the same JSON shape as
+// encodeError.
+func encodeNATSError(_ context.Context, err error, reply string, nc *nats.Conn) {
+	b, _ := json.Marshal(newErrorResponse(err))
+	nc.Publish(reply, b)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec describes the HTTP API. It is written by hand, so any change
+// to the request or response types of the operations it covers must be
+// made there too.
+//
+//go:embed openapi/openapi.json
+var openAPISpec []byte
+
+// docsPage renders openAPISpec with docsScript and docsStyle. All three are
+// served by the service itself, so the docs need no third-party assets and
+// work offline.
+//
+//go:embed openapi/docs.html
+var docsPage []byte
+
+//go:embed openapi/docs.js
+var docsScript []byte
+
+//go:embed openapi/docs.css
+var docsStyle []byte
+
+func serveOpenAPISpec(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(openAPISpec)
+}
+
+func serveDocs(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
+
+func serveDocsScript(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Write(docsScript)
+}
+
+func serveDocsStyle(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	w.Write(docsStyle)
+}
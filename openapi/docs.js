@@ -0,0 +1,110 @@
+// Renders /openapi.json as a plain HTML page. It is served by the service
+// itself, so the docs work offline and under a strict Content-Security-Policy.
+(function () {
+  "use strict";
+
+  var root = document.getElementById("docs");
+
+  function el(tag, text, className) {
+    var e = document.createElement(tag);
+    if (text !== undefined) {
+      e.textContent = text;
+    }
+    if (className) {
+      e.className = className;
+    }
+    return e;
+  }
+
+  // resolve follows a local "#/components/..." reference in spec.
+  function resolve(spec, obj) {
+    if (!obj || !obj.$ref) {
+      return obj;
+    }
+    return obj.$ref.replace(/^#\//, "").split("/").reduce(function (o, key) {
+      return o && o[key];
+    }, spec);
+  }
+
+  function schemaOf(spec, content) {
+    var media = content && content["application/json"];
+    return media ? resolve(spec, media.schema) : undefined;
+  }
+
+  function renderSchema(spec, schema) {
+    var copy = JSON.parse(JSON.stringify(schema, function (key, value) {
+      return value && value.$ref ? resolve(spec, value) : value;
+    }));
+    return el("pre", JSON.stringify(copy, null, 2));
+  }
+
+  function renderOperation(spec, path, method, op) {
+    var section = el("section", undefined, "operation");
+    var title = el("h3");
+    title.appendChild(el("span", method, "method"));
+    title.appendChild(el("code", path));
+    section.appendChild(title);
+    if (op.summary) {
+      section.appendChild(el("p", op.summary));
+    }
+
+    var params = (op.parameters || []).map(function (p) { return resolve(spec, p); });
+    if (params.length > 0) {
+      section.appendChild(el("h4", "Parameters"));
+      var ul = el("ul");
+      params.forEach(function (p) {
+        var li = el("li");
+        li.appendChild(el("code", p.name));
+        li.appendChild(document.createTextNode(
+          " (" + p["in"] + (p.required ? ", required" : "") + ")" +
+          (p.description ? ": " + p.description : "")));
+        ul.appendChild(li);
+      });
+      section.appendChild(ul);
+    }
+
+    var body = op.requestBody && schemaOf(spec, op.requestBody.content);
+    if (body) {
+      section.appendChild(el("h4", "Request body"));
+      section.appendChild(renderSchema(spec, body));
+    }
+
+    section.appendChild(el("h4", "Responses"));
+    var responses = el("ul");
+    Object.keys(op.responses || {}).forEach(function (code) {
+      var r = resolve(spec, op.responses[code]);
+      var li = el("li");
+      li.appendChild(el("code", code));
+      li.appendChild(document.createTextNode(" " + (r.description || "")));
+      responses.appendChild(li);
+    });
+    section.appendChild(responses);
+    return section;
+  }
+
+  function render(spec) {
+    root.textContent = "";
+    root.appendChild(el("h1", spec.info.title + " " + spec.info.version));
+    if (spec.info.description) {
+      root.appendChild(el("p", spec.info.description));
+    }
+    Object.keys(spec.paths).forEach(function (path) {
+      var item = spec.paths[path];
+      Object.keys(item).forEach(function (method) {
+        root.appendChild(renderOperation(spec, path, method, item[method]));
+      });
+    });
+  }
+
+  fetch("/openapi.json")
+    .then(function (r) {
+      if (!r.ok) {
+        throw new Error(r.status + " " + r.statusText);
+      }
+      return r.json();
+    })
+    .then(render)
+    .catch(function (err) {
+      root.textContent = "Could not load /openapi.json: " + err.message;
+    });
+}());
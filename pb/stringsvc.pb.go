@@ -0,0 +1,315 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: stringsvc.proto
+
+package pb
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+// The Uppercase request contains the string to transform.
+type UppercaseRequest struct {
+	S                    string   `protobuf:"bytes,1,opt,name=s" json:"s,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UppercaseRequest) Reset()         { *m = UppercaseRequest{} }
+func (m *UppercaseRequest) String() string { return proto.CompactTextString(m) }
+func (*UppercaseRequest) ProtoMessage()    {}
+func (*UppercaseRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_stringsvc_4f2a303a827bd054, []int{0}
+}
+func (m *UppercaseRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UppercaseRequest.Unmarshal(m, b)
+}
+func (m *UppercaseRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UppercaseRequest.Marshal(b, m, deterministic)
+}
+func (dst *UppercaseRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UppercaseRequest.Merge(dst, src)
+}
+func (m *UppercaseRequest) XXX_Size() int {
+	return xxx_messageInfo_UppercaseRequest.Size(m)
+}
+func (m *UppercaseRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UppercaseRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UppercaseRequest proto.InternalMessageInfo
+
+func (m *UppercaseRequest) GetS() string {
+	if m != nil {
+		return m.S
+	}
+	return ""
+}
+
+// The Uppercase response contains the result of the transformation. Errors
+// are reported as gRPC status errors.
+type UppercaseReply struct {
+	V                    string   `protobuf:"bytes,1,opt,name=v" json:"v,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UppercaseReply) Reset()         { *m = UppercaseReply{} }
+func (m *UppercaseReply) String() string { return proto.CompactTextString(m) }
+func (*UppercaseReply) ProtoMessage()    {}
+func (*UppercaseReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_stringsvc_4f2a303a827bd054, []int{1}
+}
+func (m *UppercaseReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UppercaseReply.Unmarshal(m, b)
+}
+func (m *UppercaseReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UppercaseReply.Marshal(b, m, deterministic)
+}
+func (dst *UppercaseReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UppercaseReply.Merge(dst, src)
+}
+func (m *UppercaseReply) XXX_Size() int {
+	return xxx_messageInfo_UppercaseReply.Size(m)
+}
+func (m *UppercaseReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_UppercaseReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UppercaseReply proto.InternalMessageInfo
+
+func (m *UppercaseReply) GetV() string {
+	if m != nil {
+		return m.V
+	}
+	return ""
+}
+
+// The Count request contains the string to measure.
+type CountRequest struct {
+	S                    string   `protobuf:"bytes,1,opt,name=s" json:"s,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CountRequest) Reset()         { *m = CountRequest{} }
+func (m *CountRequest) String() string { return proto.CompactTextString(m) }
+func (*CountRequest) ProtoMessage()    {}
+func (*CountRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_stringsvc_4f2a303a827bd054, []int{2}
+}
+func (m *CountRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CountRequest.Unmarshal(m, b)
+}
+func (m *CountRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CountRequest.Marshal(b, m, deterministic)
+}
+func (dst *CountRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CountRequest.Merge(dst, src)
+}
+func (m *CountRequest) XXX_Size() int {
+	return xxx_messageInfo_CountRequest.Size(m)
+}
+func (m *CountRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CountRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CountRequest proto.InternalMessageInfo
+
+func (m *CountRequest) GetS() string {
+	if m != nil {
+		return m.S
+	}
+	return ""
+}
+
+// The Count response contains the number of bytes in the string.
+type CountReply struct {
+	V                    int64    `protobuf:"varint,1,opt,name=v" json:"v,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CountReply) Reset()         { *m = CountReply{} }
+func (m *CountReply) String() string { return proto.CompactTextString(m) }
+func (*CountReply) ProtoMessage()    {}
+func (*CountReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_stringsvc_4f2a303a827bd054, []int{3}
+}
+func (m *CountReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CountReply.Unmarshal(m, b)
+}
+func (m *CountReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CountReply.Marshal(b, m, deterministic)
+}
+func (dst *CountReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CountReply.Merge(dst, src)
+}
+func (m *CountReply) XXX_Size() int {
+	return xxx_messageInfo_CountReply.Size(m)
+}
+func (m *CountReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_CountReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CountReply proto.InternalMessageInfo
+
+func (m *CountReply) GetV() int64 {
+	if m != nil {
+		return m.V
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*UppercaseRequest)(nil), "pb.UppercaseRequest")
+	proto.RegisterType((*UppercaseReply)(nil), "pb.UppercaseReply")
+	proto.RegisterType((*CountRequest)(nil), "pb.CountRequest")
+	proto.RegisterType((*CountReply)(nil), "pb.CountReply")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for StringService service
+
+type StringServiceClient interface {
+	// Uppercases a string.
+	Uppercase(ctx context.Context, in *UppercaseRequest, opts ...grpc.CallOption) (*UppercaseReply, error)
+	// Counts the bytes in a string.
+	Count(ctx context.Context, in *CountRequest, opts ...grpc.CallOption) (*CountReply, error)
+}
+
+type stringServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewStringServiceClient(cc *grpc.ClientConn) StringServiceClient {
+	return &stringServiceClient{cc}
+}
+
+func (c *stringServiceClient) Uppercase(ctx context.Context, in *UppercaseRequest, opts ...grpc.CallOption) (*UppercaseReply, error) {
+	out := new(UppercaseReply)
+	err := grpc.Invoke(ctx, "/pb.StringService/Uppercase", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stringServiceClient) Count(ctx context.Context, in *CountRequest, opts ...grpc.CallOption) (*CountReply, error) {
+	out := new(CountReply)
+	err := grpc.Invoke(ctx, "/pb.StringService/Count", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for StringService service
+
+type StringServiceServer interface {
+	// Uppercases a string.
+	Uppercase(context.Context, *UppercaseRequest) (*UppercaseReply, error)
+	// Counts the bytes in a string.
+	Count(context.Context, *CountRequest) (*CountReply, error)
+}
+
+func RegisterStringServiceServer(s *grpc.Server, srv StringServiceServer) {
+	s.RegisterService(&_StringService_serviceDesc, srv)
+}
+
+func _StringService_Uppercase_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UppercaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StringServiceServer).Uppercase(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.StringService/Uppercase",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StringServiceServer).Uppercase(ctx, req.(*UppercaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StringService_Count_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StringServiceServer).Count(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.StringService/Count",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StringServiceServer).Count(ctx, req.(*CountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _StringService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.StringService",
+	HandlerType: (*StringServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Uppercase",
+			Handler:    _StringService_Uppercase_Handler,
+		},
+		{
+			MethodName: "Count",
+			Handler:    _StringService_Count_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "stringsvc.proto",
+}
+
+func init() { proto.RegisterFile("stringsvc.proto", fileDescriptor_stringsvc_4f2a303a827bd054) }
+
+var fileDescriptor_stringsvc_4f2a303a827bd054 = []byte{
+	// 180 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x2f, 0x2e, 0x29, 0xca,
+	0xcc, 0x4b, 0x2f, 0x2e, 0x4b, 0xd6, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x2a, 0x48, 0x52,
+	0x52, 0xe0, 0x12, 0x08, 0x2d, 0x28, 0x48, 0x2d, 0x4a, 0x4e, 0x2c, 0x4e, 0x0d, 0x4a, 0x2d, 0x2c,
+	0x4d, 0x2d, 0x2e, 0x11, 0xe2, 0xe1, 0x62, 0x2c, 0x96, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x0c, 0x62,
+	0x2c, 0x56, 0x52, 0xe1, 0xe2, 0x43, 0x52, 0x51, 0x90, 0x53, 0x09, 0x92, 0x2f, 0x83, 0xc9, 0x97,
+	0x79, 0xb1, 0x70, 0x30, 0x09, 0x30, 0x2b, 0xc9, 0x70, 0xf1, 0x38, 0xe7, 0x97, 0xe6, 0x95, 0x60,
+	0x37, 0x43, 0x81, 0x8b, 0x0b, 0x2a, 0x8b, 0xa2, 0x9f, 0x19, 0xae, 0xdf, 0xa8, 0x94, 0x8b, 0x37,
+	0x18, 0xec, 0xbc, 0xe0, 0xd4, 0xa2, 0xb2, 0xcc, 0xe4, 0x54, 0x21, 0x73, 0x2e, 0x4e, 0xb8, 0xb5,
+	0x42, 0x22, 0x7a, 0x05, 0x49, 0x7a, 0xe8, 0xee, 0x94, 0x12, 0x42, 0x13, 0x2d, 0xc8, 0xa9, 0x54,
+	0x62, 0x10, 0xd2, 0xe6, 0x62, 0x05, 0xdb, 0x25, 0x24, 0x00, 0x92, 0x46, 0x76, 0x94, 0x14, 0x1f,
+	0x92, 0x08, 0x58, 0x71, 0x12, 0x1b, 0x38, 0x24, 0x8c, 0x01, 0x03, 0x00, 0xb3, 0xd5, 0x6c, 0x33,
+	0x1c, 0x01, 0x00, 0x00,
+}
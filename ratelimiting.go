@@ -0,0 +1,432 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/peer"
+)
+
+// ErrRateLimited is returned when a call exceeds the configured rate limit.
+var ErrRateLimited = &ServiceError{Code: "rate_limited", Status: http.StatusTooManyRequests, Message: "rate limit exceeded"}
+
+// rateLimitingMiddleware rejects calls that exceed a per-method token bucket
+// or, if clients is set, the caller's own token bucket.
+type rateLimitingMiddleware struct {
+	limiters *methodLimiters
+	clients  *clientLimiters
+	next     StringService
+}
+
+// newRateLimitingMiddleware returns a Middleware that allows each method up
+// to limit calls per second, with bursts of up to burst calls. If clients is
+// non-nil, each caller is also held to its own limit across all methods.
+func newRateLimitingMiddleware(limit float64, burst int, clients *clientLimiters) Middleware {
+	limiters := &methodLimiters{
+		limit:    rate.Limit(limit),
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+	return func(next StringService) StringService {
+		return rateLimitingMiddleware{limiters, clients, next}
+	}
+}
+
+// methodLimiters holds a token bucket for each method, created on the
+// method's first call.
+type methodLimiters struct {
+	limit rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func (l *methodLimiters) get(method string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, ok := l.limiters[method]
+	if !ok {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.limiters[method] = limiter
+	}
+	return limiter
+}
+
+func (mw rateLimitingMiddleware) allow(ctx context.Context, method string) error {
+	// Check the caller's own bucket first so that a client over its limit
+	// doesn't also use up the shared budget.
+	if mw.clients != nil && !mw.clients.allow(ctx) {
+		return ErrRateLimited
+	}
+	if !mw.limiters.get(method).Allow() {
+		return ErrRateLimited
+	}
+	return nil
+}
+
+func (mw rateLimitingMiddleware) Uppercase(ctx context.Context, s string) (string, error) {
+	if err := mw.allow(ctx, "uppercase"); err != nil {
+		return "", err
+	}
+	return mw.next.Uppercase(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) Lowercase(ctx context.Context, s string) (string, error) {
+	if err := mw.allow(ctx, "lowercase"); err != nil {
+		return "", err
+	}
+	return mw.next.Lowercase(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) Count(ctx context.Context, s string) (Counts, error) {
+	if err := mw.allow(ctx, "count"); err != nil {
+		return Counts{}, err
+	}
+	return mw.next.Count(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) Reverse(ctx context.Context, s string) (string, error) {
+	if err := mw.allow(ctx, "reverse"); err != nil {
+		return "", err
+	}
+	return mw.next.Reverse(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) Trim(ctx context.Context, s string, cutset string) (string, error) {
+	if err := mw.allow(ctx, "trim"); err != nil {
+		return "", err
+	}
+	return mw.next.Trim(ctx, s, cutset)
+}
+
+func (mw rateLimitingMiddleware) UppercaseBatch(ctx context.Context, ss []string) ([]string, []error) {
+	if err := mw.allow(ctx, "uppercase_batch"); err != nil {
+		errs := make([]error, len(ss))
+		for i := range errs {
+			errs[i] = err
+		}
+		return make([]string, len(ss)), errs
+	}
+	return mw.next.UppercaseBatch(ctx, ss)
+}
+
+func (mw rateLimitingMiddleware) WordCount(ctx context.Context, s string) (int, error) {
+	if err := mw.allow(ctx, "wordcount"); err != nil {
+		return 0, err
+	}
+	return mw.next.WordCount(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) RuneCount(ctx context.Context, s string) (int, error) {
+	if err := mw.allow(ctx, "runecount"); err != nil {
+		return 0, err
+	}
+	return mw.next.RuneCount(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) Concat(ctx context.Context, ss []string, sep string) (string, error) {
+	if err := mw.allow(ctx, "concat"); err != nil {
+		return "", err
+	}
+	return mw.next.Concat(ctx, ss, sep)
+}
+
+func (mw rateLimitingMiddleware) Replace(ctx context.Context, s, old, new string, n int) (string, error) {
+	if err := mw.allow(ctx, "replace"); err != nil {
+		return "", err
+	}
+	return mw.next.Replace(ctx, s, old, new, n)
+}
+
+func (mw rateLimitingMiddleware) Contains(ctx context.Context, s, substr string) (bool, error) {
+	if err := mw.allow(ctx, "contains"); err != nil {
+		return false, err
+	}
+	return mw.next.Contains(ctx, s, substr)
+}
+
+func (mw rateLimitingMiddleware) Split(ctx context.Context, s, sep string) ([]string, error) {
+	if err := mw.allow(ctx, "split"); err != nil {
+		return nil, err
+	}
+	return mw.next.Split(ctx, s, sep)
+}
+
+func (mw rateLimitingMiddleware) Title(ctx context.Context, s string) (string, error) {
+	if err := mw.allow(ctx, "title"); err != nil {
+		return "", err
+	}
+	return mw.next.Title(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) Pad(ctx context.Context, s string, width int, pad string, left bool) (string, error) {
+	if err := mw.allow(ctx, "pad"); err != nil {
+		return "", err
+	}
+	return mw.next.Pad(ctx, s, width, pad, left)
+}
+
+func (mw rateLimitingMiddleware) Capitalize(ctx context.Context, s string) (string, error) {
+	if err := mw.allow(ctx, "capitalize"); err != nil {
+		return "", err
+	}
+	return mw.next.Capitalize(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) Base64Encode(ctx context.Context, s string) (string, error) {
+	if err := mw.allow(ctx, "base64_encode"); err != nil {
+		return "", err
+	}
+	return mw.next.Base64Encode(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) Base64Decode(ctx context.Context, s string) (string, error) {
+	if err := mw.allow(ctx, "base64_decode"); err != nil {
+		return "", err
+	}
+	return mw.next.Base64Decode(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) Hash(ctx context.Context, s, algo string) (string, error) {
+	if err := mw.allow(ctx, "hash"); err != nil {
+		return "", err
+	}
+	return mw.next.Hash(ctx, s, algo)
+}
+
+func (mw rateLimitingMiddleware) UppercaseExcept(ctx context.Context, s, except string) (string, error) {
+	if err := mw.allow(ctx, "uppercase_except"); err != nil {
+		return "", err
+	}
+	return mw.next.UppercaseExcept(ctx, s, except)
+}
+
+func (mw rateLimitingMiddleware) CharFrequency(ctx context.Context, s string) (map[string]int, error) {
+	if err := mw.allow(ctx, "charfrequency"); err != nil {
+		return nil, err
+	}
+	return mw.next.CharFrequency(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) Distance(ctx context.Context, a, b string) (int, error) {
+	if err := mw.allow(ctx, "distance"); err != nil {
+		return 0, err
+	}
+	return mw.next.Distance(ctx, a, b)
+}
+
+func (mw rateLimitingMiddleware) Repeat(ctx context.Context, s string, count int) (string, error) {
+	if err := mw.allow(ctx, "repeat"); err != nil {
+		return "", err
+	}
+	return mw.next.Repeat(ctx, s, count)
+}
+
+func (mw rateLimitingMiddleware) Truncate(ctx context.Context, s string, max int, ellipsis bool) (string, error) {
+	if err := mw.allow(ctx, "truncate"); err != nil {
+		return "", err
+	}
+	return mw.next.Truncate(ctx, s, max, ellipsis)
+}
+
+func (mw rateLimitingMiddleware) IsPalindrome(ctx context.Context, s string, strip bool) (bool, error) {
+	if err := mw.allow(ctx, "palindrome"); err != nil {
+		return false, err
+	}
+	return mw.next.IsPalindrome(ctx, s, strip)
+}
+
+func (mw rateLimitingMiddleware) Normalize(ctx context.Context, s, form string) (string, error) {
+	if err := mw.allow(ctx, "normalize"); err != nil {
+		return "", err
+	}
+	return mw.next.Normalize(ctx, s, form)
+}
+
+func (mw rateLimitingMiddleware) Soundex(ctx context.Context, s string) (string, error) {
+	if err := mw.allow(ctx, "soundex"); err != nil {
+		return "", err
+	}
+	return mw.next.Soundex(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) CountSubstr(ctx context.Context, s, substr string) (int, error) {
+	if err := mw.allow(ctx, "countsubstr"); err != nil {
+		return 0, err
+	}
+	return mw.next.CountSubstr(ctx, s, substr)
+}
+
+func (mw rateLimitingMiddleware) ConvertCase(ctx context.Context, s, to string) (string, error) {
+	if err := mw.allow(ctx, "convertcase"); err != nil {
+		return "", err
+	}
+	return mw.next.ConvertCase(ctx, s, to)
+}
+
+func (mw rateLimitingMiddleware) RegexFind(ctx context.Context, s, pattern string) ([]string, error) {
+	if err := mw.allow(ctx, "regex_find"); err != nil {
+		return nil, err
+	}
+	return mw.next.RegexFind(ctx, s, pattern)
+}
+
+func (mw rateLimitingMiddleware) CollapseSpaces(ctx context.Context, s string) (string, error) {
+	if err := mw.allow(ctx, "collapse"); err != nil {
+		return "", err
+	}
+	return mw.next.CollapseSpaces(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) DetectScript(ctx context.Context, s string) (string, error) {
+	if err := mw.allow(ctx, "detectscript"); err != nil {
+		return "", err
+	}
+	return mw.next.DetectScript(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) URLEncode(ctx context.Context, s string) (string, error) {
+	if err := mw.allow(ctx, "url_encode"); err != nil {
+		return "", err
+	}
+	return mw.next.URLEncode(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) URLDecode(ctx context.Context, s string) (string, error) {
+	if err := mw.allow(ctx, "url_decode"); err != nil {
+		return "", err
+	}
+	return mw.next.URLDecode(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) Slugify(ctx context.Context, s string) (string, error) {
+	if err := mw.allow(ctx, "slugify"); err != nil {
+		return "", err
+	}
+	return mw.next.Slugify(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) Mask(ctx context.Context, s string, visible int, mask string) (string, error) {
+	if err := mw.allow(ctx, "mask"); err != nil {
+		return "", err
+	}
+	return mw.next.Mask(ctx, s, visible, mask)
+}
+
+func (mw rateLimitingMiddleware) LineCount(ctx context.Context, s string) (int, error) {
+	if err := mw.allow(ctx, "linecount"); err != nil {
+		return 0, err
+	}
+	return mw.next.LineCount(ctx, s)
+}
+
+func (mw rateLimitingMiddleware) UppercaseStream(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	if err := mw.allow(ctx, "uppercase_stream"); err != nil {
+		return 0, err
+	}
+	return mw.next.UppercaseStream(ctx, dst, src)
+}
+
+// Health is never rate limited so that probes keep working under load.
+func (mw rateLimitingMiddleware) Health(ctx context.Context) (bool, error) {
+	return mw.next.Health(ctx)
+}
+
+// Version is not rate limited either; it is cheap and used by deploy checks.
+func (mw rateLimitingMiddleware) Version(ctx context.Context) (BuildInfo, error) {
+	return mw.next.Version(ctx)
+}
+
+// clientIdleTimeout is how long a client's limiter is kept after its last
+// call.
+const clientIdleTimeout = 10 * time.Minute
+
+// clientLimiters holds a token bucket per caller. A caller is identified by
+// its API key when keys are in use, and by its remote IP otherwise. Calls
+// from callers that can't be identified, such as those arriving over NATS,
+// are held only to the per-method limits.
+type clientLimiters struct {
+	limit   rate.Limit
+	burst   int
+	useKeys bool
+
+	mu       sync.Mutex
+	limiters map[string]*clientLimiter
+}
+
+type clientLimiter struct {
+	*rate.Limiter
+	lastSeen time.Time
+}
+
+// newClientLimiters returns clientLimiters that allow each caller up to
+// limit calls per second, with bursts of up to burst calls. useKeys should
+// only be set when API keys are enforced; otherwise any caller could pick a
+// fresh key for every call. Limiters idle for clientIdleTimeout are evicted.
+func newClientLimiters(limit float64, burst int, useKeys bool) *clientLimiters {
+	c := &clientLimiters{
+		limit:    rate.Limit(limit),
+		burst:    burst,
+		useKeys:  useKeys,
+		limiters: make(map[string]*clientLimiter),
+	}
+	go c.evictIdle()
+	return c
+}
+
+func (c *clientLimiters) allow(ctx context.Context) bool {
+	id := c.clientID(ctx)
+	if id == "" {
+		return true
+	}
+	now := time.Now()
+	c.mu.Lock()
+	l, ok := c.limiters[id]
+	if !ok {
+		l = &clientLimiter{Limiter: rate.NewLimiter(c.limit, c.burst)}
+		c.limiters[id] = l
+	}
+	l.lastSeen = now
+	c.mu.Unlock()
+	return l.AllowN(now, 1)
+}
+
+// clientID identifies the caller of ctx, or returns the empty string if it
+// can't.
+func (c *clientLimiters) clientID(ctx context.Context) string {
+	if key, _ := ctx.Value(apiKeyContextKey).(string); c.useKeys && key != "" {
+		return "key:" + key
+	}
+	var addr string
+	if a, ok := ctx.Value(httptransport.ContextKeyRequestRemoteAddr).(string); ok {
+		addr = a
+	} else if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+	if addr == "" {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return "ip:" + addr
+}
+
+func (c *clientLimiters) evictIdle() {
+	for now := range time.Tick(clientIdleTimeout) {
+		c.mu.Lock()
+		for id, l := range c.limiters {
+			if now.Sub(l.lastSeen) > clientIdleTimeout {
+				delete(c.limiters, id)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/metrics"
+)
+
+// ErrInternal is returned in place of a panic. It deliberately says nothing
+// about the cause; the details are in the logs.
+var ErrInternal = &ServiceError{Code: "internal", Status: http.StatusInternalServerError, Message: "internal error"}
+
+// recoveryMiddleware turns a panic in the wrapped endpoint into ErrInternal,
+// after logging it with a stack trace and counting it in panics.
+func recoveryMiddleware(logger log.Logger, panics metrics.Counter, method string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					panics.With("method", method).Add(1)
+					level.Error(logger).Log(
+						"method", method,
+						"request_id", requestIDFromContext(ctx),
+						"panic", r,
+						"stack", string(debug.Stack()),
+					)
+					response, err = nil, ErrInternal
+				}
+			}()
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type contextKey int
+
+const (
+	requestIDContextKey contextKey = iota
+	apiKeyContextKey
+	validateOnlyContextKey
+	deadlineCancelContextKey
+	languageContextKey
+	idempotencyKeyContextKey
+	serverTimingContextKey
+)
+
+// requestIDHeader carries the ID used to correlate a request across logs.
+const requestIDHeader = "X-Request-ID"
+
+// populateRequestID is a ServerBefore hook that stores the caller's
+// X-Request-ID in the context, generating a new one if the header is absent.
+func populateRequestID(ctx context.Context, r *http.Request) context.Context {
+	id := r.Header.Get(requestIDHeader)
+	if id == "" {
+		id = uuid.New().String()
+	}
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// echoRequestID is a ServerAfter hook that returns the request ID to the
+// caller in the X-Request-ID response header.
+func echoRequestID(ctx context.Context, w http.ResponseWriter) context.Context {
+	if id := requestIDFromContext(ctx); id != "" {
+		w.Header().Set(requestIDHeader, id)
+	}
+	return ctx
+}
+
+// requestIDFromContext returns the request ID stored in ctx, or the empty
+// string if there is none.
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
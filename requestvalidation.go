@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// ErrValidation is the ServiceError behind every ValidationError. Use
+// errors.Is to test for it.
+var ErrValidation = &ServiceError{Code: "validation", Status: http.StatusUnprocessableEntity, Message: "validation failed"}
+
+// ValidationError reports which fields of a request are invalid, and why.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e ValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = name + ": " + e.Fields[name]
+	}
+	return ErrValidation.Message + ": " + strings.Join(msgs, "; ")
+}
+
+func (e ValidationError) Unwrap() error { return ErrValidation }
+
+// validator is implemented by requests that can check their own fields. It
+// returns a ValidationError, or nil if the request is valid.
+type validator interface {
+	validate() error
+}
+
+// requestValidationMiddleware rejects requests that implement validator and
+// fail validation, before they reach the service. Other requests pass
+// through unchecked.
+func requestValidationMiddleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if v, ok := request.(validator); ok {
+			if err := v.validate(); err != nil {
+				return nil, err
+			}
+		}
+		return next(ctx, request)
+	}
+}
+
+func (r uppercaseRequest) validate() error {
+	if r.S == "" {
+		return ValidationError{map[string]string{"s": "is required"}}
+	}
+	return nil
+}
+
+func (r countRequest) validate() error {
+	if r.S == "" {
+		return ValidationError{map[string]string{"s": "is required"}}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// RetriableError is implemented by errors that are worth retrying, such as
+// transient failures of a downstream dependency. Errors that don't implement
+// it, like ErrEmpty, are permanent and never retried.
+type RetriableError interface {
+	error
+	Retriable() bool
+}
+
+// retryMiddleware retries calls that fail with a RetriableError up to max
+// more times. The delay before the first retry is backoff and doubles with
+// each further attempt. Retrying stops early if ctx is done.
+func retryMiddleware(max int, backoff time.Duration) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			delay := backoff
+			for attempt := 0; ; attempt++ {
+				response, err := next(ctx, request)
+				if err == nil || attempt >= max || !isRetriable(err) {
+					return response, err
+				}
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				delay *= 2
+			}
+		}
+	}
+}
+
+// isRetriable reports whether err, or any error it wraps, is a
+// RetriableError that asks to be retried.
+func isRetriable(err error) bool {
+	var r RetriableError
+	return errors.As(err, &r) && r.Retriable()
+}
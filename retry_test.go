@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type transientError struct{}
+
+func (transientError) Error() string   { return "transient" }
+func (transientError) Retriable() bool { return true }
+
+func TestRetryWrappedError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int // calls
+	}{
+		{"retriable", transientError{}, 3},
+		{"wrapped retriable", fmt.Errorf("calling backend: %w", transientError{}), 3},
+		{"permanent", ErrEmpty, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			e := retryMiddleware(2, time.Millisecond)(func(context.Context, interface{}) (interface{}, error) {
+				calls++
+				return nil, tt.err
+			})
+			if _, err := e(context.Background(), nil); err != tt.err {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+			if calls != tt.want {
+				t.Errorf("calls = %d, want %d", calls, tt.want)
+			}
+		})
+	}
+}
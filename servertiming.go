@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+)
+
+// serverTimingHeader reports how long each stage of a request took, in the
+// format browsers show in their developer tools.
+const serverTimingHeader = "Server-Timing"
+
+// serverTiming records when each stage of an HTTP request ended. It is
+// stored in the context by startServerTiming and filled in as the request
+// moves through the decoder, the endpoint and the encoder.
+type serverTiming struct {
+	start   time.Time
+	decoded time.Time
+}
+
+// startServerTiming is a ServerBefore hook that starts timing the request.
+// It must come before the other hooks so that their work counts towards
+// decoding.
+func startServerTiming(ctx context.Context, _ *http.Request) context.Context {
+	return context.WithValue(ctx, serverTimingContextKey, &serverTiming{start: time.Now()})
+}
+
+func serverTimingFromContext(ctx context.Context) *serverTiming {
+	t, _ := ctx.Value(serverTimingContextKey).(*serverTiming)
+	return t
+}
+
+// header formats the stages that had finished at end, the time the endpoint
+// returned or failed. A request that failed to decode only has a decode
+// stage, and encoded is zero unless the response was timed by timeEncode.
+func (t *serverTiming) header(end, encoded time.Time) string {
+	if t.decoded.IsZero() {
+		return timingMetric("decode", end.Sub(t.start))
+	}
+	metrics := []string{
+		timingMetric("decode", t.decoded.Sub(t.start)),
+		timingMetric("endpoint", end.Sub(t.decoded)),
+	}
+	if !encoded.IsZero() {
+		metrics = append(metrics, timingMetric("encode", encoded.Sub(end)))
+	}
+	return strings.Join(metrics, ", ")
+}
+
+func timingMetric(name string, d time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.3f", name, float64(d)/float64(time.Millisecond))
+}
+
+// timeDecode wraps dec so that the end of decoding is recorded.
+func timeDecode(dec httptransport.DecodeRequestFunc) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		request, err := dec(ctx, r)
+		if t := serverTimingFromContext(ctx); t != nil && err == nil {
+			t.decoded = time.Now()
+		}
+		return request, err
+	}
+}
+
+// timeEncode wraps enc so that the response carries a Server-Timing header.
+// The header has to be written before the body, so the response is encoded
+// into a buffer first and only sent once the encode stage has been timed.
+func timeEncode(enc httptransport.EncodeResponseFunc) httptransport.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		t := serverTimingFromContext(ctx)
+		if t == nil {
+			return enc(ctx, w, response)
+		}
+		begin := time.Now()
+		bw := &bufferingWriter{header: w.Header(), code: http.StatusOK}
+		if err := enc(ctx, bw, response); err != nil {
+			return err
+		}
+		w.Header().Set(serverTimingHeader, t.header(begin, time.Now()))
+		w.WriteHeader(bw.code)
+		_, err := w.Write(bw.body.Bytes())
+		return err
+	}
+}
+
+// timeErrors wraps ee so that error responses carry a Server-Timing header
+// for the stages that ran before the error.
+func timeErrors(ee httptransport.ErrorEncoder) httptransport.ErrorEncoder {
+	return func(ctx context.Context, err error, w http.ResponseWriter) {
+		if t := serverTimingFromContext(ctx); t != nil {
+			w.Header().Set(serverTimingHeader, t.header(time.Now(), time.Time{}))
+		}
+		ee(ctx, err, w)
+	}
+}
+
+// bufferingWriter holds on to a response until timeEncode sends it. Headers
+// go straight to the real response, since nothing is sent before the body.
+type bufferingWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (w *bufferingWriter) Header() http.Header         { return w.header }
+func (w *bufferingWriter) WriteHeader(code int)        { w.code = code }
+func (w *bufferingWriter) Write(p []byte) (int, error) { return w.body.Write(p) }
@@ -1,28 +1,833 @@
 package main
 
 import (
-	"errors"
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 // StringService provides operations on strings.
 type StringService interface {
-	Uppercase(string) (string, error)
-	Count(string) int
+	Uppercase(context.Context, string) (string, error)
+	Lowercase(context.Context, string) (string, error)
+	Count(context.Context, string) (Counts, error)
+	Reverse(context.Context, string) (string, error)
+	Trim(ctx context.Context, s string, cutset string) (string, error)
+	Health(context.Context) (bool, error)
+	UppercaseBatch(context.Context, []string) ([]string, []error)
+	WordCount(context.Context, string) (int, error)
+	RuneCount(context.Context, string) (int, error)
+	Concat(ctx context.Context, ss []string, sep string) (string, error)
+	Replace(ctx context.Context, s, old, new string, n int) (string, error)
+	Contains(ctx context.Context, s, substr string) (bool, error)
+	Split(ctx context.Context, s, sep string) ([]string, error)
+	Title(context.Context, string) (string, error)
+	Version(context.Context) (BuildInfo, error)
+	Pad(ctx context.Context, s string, width int, pad string, left bool) (string, error)
+	Capitalize(context.Context, string) (string, error)
+	Base64Encode(context.Context, string) (string, error)
+	Base64Decode(context.Context, string) (string, error)
+	Hash(ctx context.Context, s, algo string) (string, error)
+	UppercaseExcept(ctx context.Context, s, except string) (string, error)
+	CharFrequency(context.Context, string) (map[string]int, error)
+	Distance(ctx context.Context, a, b string) (int, error)
+	Repeat(ctx context.Context, s string, count int) (string, error)
+	Truncate(ctx context.Context, s string, max int, ellipsis bool) (string, error)
+	IsPalindrome(ctx context.Context, s string, strip bool) (bool, error)
+	Normalize(ctx context.Context, s, form string) (string, error)
+	Soundex(context.Context, string) (string, error)
+	CountSubstr(ctx context.Context, s, substr string) (int, error)
+	ConvertCase(ctx context.Context, s, to string) (string, error)
+	RegexFind(ctx context.Context, s, pattern string) ([]string, error)
+	CollapseSpaces(context.Context, string) (string, error)
+	DetectScript(context.Context, string) (string, error)
+	URLEncode(context.Context, string) (string, error)
+	URLDecode(context.Context, string) (string, error)
+	Slugify(context.Context, string) (string, error)
+	Mask(ctx context.Context, s string, visible int, mask string) (string, error)
+	LineCount(context.Context, string) (int, error)
+	UppercaseStream(ctx context.Context, dst io.Writer, src io.Reader) (int64, error)
 }
 
 type stringService struct{}
 
-func (stringService) Uppercase(s string) (string, error) {
+// Uppercase follows the rules of the caller's language if ctx carries one,
+// so that "i" becomes "İ" in Turkish. As with Count, an empty s sent to the
+// endpoint is rejected with a 422 ErrValidation before it gets here; the
+// ErrEmpty it returns is seen by direct callers and by each empty item of
+// UppercaseBatch.
+func (stringService) Uppercase(ctx context.Context, s string) (string, error) {
 	if s == "" {
 		return "", ErrEmpty
 	}
+	if tag, ok := languageFromContext(ctx); ok {
+		return cases.Upper(tag).String(s), nil
+	}
 	return strings.ToUpper(s), nil
 }
 
-func (stringService) Count(s string) int {
-	return len(s)
+// Lowercase follows the rules of the caller's language if ctx carries one.
+func (stringService) Lowercase(ctx context.Context, s string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	if tag, ok := languageFromContext(ctx); ok {
+		return cases.Lower(tag).String(s), nil
+	}
+	return strings.ToLower(s), nil
+}
+
+// Counts holds the length of a string measured in bytes and in runes.
+type Counts struct {
+	Bytes int
+	Runes int
+}
+
+// Count returns ErrEmpty for an empty s. Requests to the count endpoint are
+// rejected earlier, with a 422 ErrValidation from
+// requestValidationMiddleware, so ErrEmpty only reaches direct callers of
+// the service.
+func (stringService) Count(_ context.Context, s string) (Counts, error) {
+	if s == "" {
+		return Counts{}, ErrEmpty
+	}
+	return Counts{Bytes: len(s), Runes: utf8.RuneCountInString(s)}, nil
+}
+
+func (stringService) Reverse(_ context.Context, s string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	// Reverse runes rather than bytes so multi-byte characters stay intact.
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r), nil
+}
+
+func (stringService) Trim(_ context.Context, s string, cutset string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	if cutset == "" {
+		return strings.TrimSpace(s), nil
+	}
+	return strings.Trim(s, cutset), nil
+}
+
+func (stringService) Health(_ context.Context) (bool, error) {
+	return true, nil
+}
+
+// UppercaseBatch uppercases each string independently, reporting a
+// per-item error in the same position as the input.
+func (svc stringService) UppercaseBatch(ctx context.Context, ss []string) ([]string, []error) {
+	vs := make([]string, len(ss))
+	errs := make([]error, len(ss))
+	for i, s := range ss {
+		vs[i], errs[i] = svc.Uppercase(ctx, s)
+	}
+	return vs, errs
+}
+
+// WordCount returns the number of whitespace-separated words in s.
+func (stringService) WordCount(_ context.Context, s string) (int, error) {
+	n := len(strings.Fields(s))
+	if n == 0 {
+		return 0, ErrEmpty
+	}
+	return n, nil
+}
+
+// RuneCount returns the number of characters in s, unlike Count which
+// returns the number of bytes.
+func (stringService) RuneCount(_ context.Context, s string) (int, error) {
+	if s == "" {
+		return 0, ErrEmpty
+	}
+	return utf8.RuneCountInString(s), nil
+}
+
+func (stringService) Concat(_ context.Context, ss []string, sep string) (string, error) {
+	if len(ss) == 0 {
+		return "", ErrEmpty
+	}
+	return strings.Join(ss, sep), nil
+}
+
+// Replace returns s with the first n occurrences of old replaced by new. If
+// n < 0, every occurrence is replaced.
+func (stringService) Replace(_ context.Context, s, old, new string, n int) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	return strings.Replace(s, old, new, n), nil
+}
+
+func (stringService) Contains(_ context.Context, s, substr string) (bool, error) {
+	if s == "" {
+		return false, ErrEmpty
+	}
+	return strings.Contains(s, substr), nil
+}
+
+// Split slices s into the substrings separated by sep. An empty sep splits s
+// into its individual runes, as strings.Split does.
+func (stringService) Split(_ context.Context, s, sep string) ([]string, error) {
+	if s == "" {
+		return nil, ErrEmpty
+	}
+	return strings.Split(s, sep), nil
+}
+
+// Title upper-cases the first letter of each word in s using Unicode title
+// casing rules.
+func (stringService) Title(ctx context.Context, s string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	tag, ok := languageFromContext(ctx)
+	if !ok {
+		tag = language.Und
+	}
+	// A Caser keeps state between calls, so it can't be shared.
+	return cases.Title(tag).String(s), nil
+}
+
+func (stringService) Version(_ context.Context) (BuildInfo, error) {
+	return BuildInfo{Version: version, GitCommit: gitCommit, BuildTime: buildTime}, nil
+}
+
+// Pad pads s with repetitions of pad until it is width runes long, on the
+// left if left is set and on the right otherwise. Strings that are already
+// wide enough are returned unchanged.
+func (stringService) Pad(_ context.Context, s string, width int, pad string, left bool) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return s, nil
+	}
+	if pad == "" {
+		return "", ErrEmptyPad
+	}
+	p := []rune(strings.Repeat(pad, n/utf8.RuneCountInString(pad)+1))[:n]
+	if left {
+		return string(p) + s, nil
+	}
+	return s + string(p), nil
+}
+
+// Capitalize upper-cases the first rune of s and leaves the rest as is.
+func (stringService) Capitalize(_ context.Context, s string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:], nil
+}
+
+// Base64Encode encodes s with the standard, padded base64 alphabet.
+func (stringService) Base64Encode(_ context.Context, s string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	return base64.StdEncoding.EncodeToString([]byte(s)), nil
+}
+
+// Base64Decode reverses Base64Encode.
+func (stringService) Base64Decode(_ context.Context, s string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", ErrInvalidBase64
+	}
+	return string(b), nil
+}
+
+// Hash returns the hex digest of s computed with algo, which is one of
+// "sha256", "sha1" or "md5".
+func (stringService) Hash(_ context.Context, s, algo string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	var h hash.Hash
+	switch algo {
+	case "sha256":
+		h = sha256.New()
+	case "sha1":
+		h = sha1.New()
+	case "md5":
+		h = md5.New()
+	default:
+		return "", ErrUnknownAlgorithm
+	}
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// UppercaseExcept upper-cases s but leaves the runes found in except as they
+// are.
+func (stringService) UppercaseExcept(_ context.Context, s, except string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(except, r) {
+			return r
+		}
+		return unicode.ToUpper(r)
+	}, s), nil
+}
+
+// CharFrequency counts how often each rune occurs in s.
+func (stringService) CharFrequency(_ context.Context, s string) (map[string]int, error) {
+	if s == "" {
+		return nil, ErrEmpty
+	}
+	freq := make(map[string]int)
+	for _, r := range s {
+		freq[string(r)]++
+	}
+	return freq, nil
+}
+
+// Distance returns the Levenshtein distance between a and b, counted in
+// runes. Either string may be empty.
+func (stringService) Distance(_ context.Context, a, b string) (int, error) {
+	ra, rb := []rune(a), []rune(b)
+	// Only the previous row of the edit matrix is needed to compute the next.
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)], nil
+}
+
+// Repeat returns count copies of s joined together.
+func (stringService) Repeat(_ context.Context, s string, count int) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	if count < 0 {
+		return "", ErrNegativeCount
+	}
+	return strings.Repeat(s, count), nil
 }
 
+// Truncate cuts s down to at most max runes. If ellipsis is set and s is
+// cut, the last of those runes is "…".
+func (stringService) Truncate(_ context.Context, s string, max int, ellipsis bool) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	if max < 0 {
+		return "", ErrNegativeMax
+	}
+	if utf8.RuneCountInString(s) <= max {
+		return s, nil
+	}
+	if ellipsis && max > 0 {
+		max--
+	} else {
+		ellipsis = false
+	}
+	// Ranging over s yields the byte offset of each rune, so cutting there
+	// never splits one.
+	var n int
+	for i := range s {
+		if n == max {
+			s = s[:i]
+			break
+		}
+		n++
+	}
+	if ellipsis {
+		s += "…"
+	}
+	return s, nil
+}
+
+// IsPalindrome reports whether s reads the same backwards, comparing runes
+// case-insensitively. If strip is set, only letters and digits are compared,
+// so "A man, a plan, a canal: Panama" is a palindrome.
+func (stringService) IsPalindrome(_ context.Context, s string, strip bool) (bool, error) {
+	if s == "" {
+		return false, ErrEmpty
+	}
+	var rs []rune
+	for _, r := range s {
+		if strip && !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+			continue
+		}
+		rs = append(rs, unicode.ToLower(r))
+	}
+	for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+		if rs[i] != rs[j] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Normalize returns s in the named Unicode normalization form: NFC, NFD,
+// NFKC or NFKD.
+func (stringService) Normalize(_ context.Context, s, form string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	f, ok := normForms[strings.ToUpper(form)]
+	if !ok {
+		return "", ErrUnknownForm
+	}
+	return f.String(s), nil
+}
+
+// Soundex returns the American Soundex code of s, such as "R163" for
+// "Robert". Accents are stripped and other non-ASCII letters are ignored.
+func (stringService) Soundex(_ context.Context, s string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	code := make([]byte, 0, 4)
+	var last byte
+	// Decomposing first turns letters like "é" into "e" plus a combining
+	// mark, which is then dropped along with anything else that isn't an
+	// ASCII letter.
+	for _, r := range norm.NFD.String(s) {
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		if r < 'A' || r > 'Z' {
+			continue
+		}
+		d := soundexDigits[r-'A']
+		switch {
+		case len(code) == 0:
+			code = append(code, byte(r))
+		case d != '0' && d != last:
+			code = append(code, d)
+		}
+		// H and W don't separate letters with the same digit; vowels do.
+		if r != 'H' && r != 'W' {
+			last = d
+		}
+		if len(code) == 4 {
+			break
+		}
+	}
+	if len(code) == 0 {
+		return "", ErrNoLetters
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code), nil
+}
+
+// CountSubstr returns the number of non-overlapping occurrences of substr in
+// s, so "aa" occurs twice in "aaaa", not three times.
+func (stringService) CountSubstr(_ context.Context, s, substr string) (int, error) {
+	if s == "" {
+		return 0, ErrEmpty
+	}
+	if substr == "" {
+		return 0, ErrEmptySubstr
+	}
+	return strings.Count(s, substr), nil
+}
+
+// ConvertCase rewrites s in the case style named by to: "snake"
+// (snake_case), "camel" (camelCase), "pascal" (PascalCase) or "kebab"
+// (kebab-case).
+func (stringService) ConvertCase(_ context.Context, s, to string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	words := caseWords(s)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	switch strings.ToLower(to) {
+	case "snake":
+		return strings.Join(words, "_"), nil
+	case "kebab":
+		return strings.Join(words, "-"), nil
+	case "camel":
+		for i := 1; i < len(words); i++ {
+			words[i] = upperFirst(words[i])
+		}
+		return strings.Join(words, ""), nil
+	case "pascal":
+		for i := range words {
+			words[i] = upperFirst(words[i])
+		}
+		return strings.Join(words, ""), nil
+	}
+	return "", ErrUnknownCase
+}
+
+// RegexFind returns every non-overlapping match of the regular expression
+// pattern in s, using RE2 syntax.
+func (stringService) RegexFind(_ context.Context, s, pattern string) ([]string, error) {
+	if s == "" {
+		return nil, ErrEmpty
+	}
+	re, err := compileRegex(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches := re.FindAllString(s, -1)
+	if matches == nil {
+		matches = []string{}
+	}
+	return matches, nil
+}
+
+// CollapseSpaces replaces each run of whitespace in s with a single space
+// and trims whitespace from both ends. It returns ErrEmpty if nothing but
+// whitespace is left.
+func (stringService) CollapseSpaces(_ context.Context, s string) (string, error) {
+	v := strings.Join(strings.Fields(s), " ")
+	if v == "" {
+		return "", ErrEmpty
+	}
+	return v, nil
+}
+
+// DetectScript returns the name of the Unicode script, such as "Latin" or
+// "Cyrillic", that most of the runes in s belong to. Digits, punctuation and
+// other runes shared between scripts aren't counted; if nothing else is
+// left, the result is "Common". Ties go to the name that sorts first.
+func (stringService) DetectScript(_ context.Context, s string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	counts := make(map[string]int)
+	var last string
+	for _, r := range s {
+		// Runs of the same script are common, so try the last match first.
+		if last != "" && unicode.Is(unicode.Scripts[last], r) {
+			counts[last]++
+			continue
+		}
+		for _, name := range scriptNames {
+			if unicode.Is(unicode.Scripts[name], r) {
+				counts[name]++
+				last = name
+				break
+			}
+		}
+	}
+	best := "Common"
+	for _, name := range scriptNames {
+		if counts[name] > counts[best] {
+			best = name
+		}
+	}
+	return best, nil
+}
+
+// URLEncode escapes s for use in a URL query, as url.QueryEscape does.
+func (stringService) URLEncode(_ context.Context, s string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	return url.QueryEscape(s), nil
+}
+
+// URLDecode reverses URLEncode.
+func (stringService) URLDecode(_ context.Context, s string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	v, err := url.QueryUnescape(s)
+	if err != nil {
+		return "", ErrInvalidURLEscape
+	}
+	return v, nil
+}
+
+// Slugify turns s into a lower-case, URL-friendly slug such as
+// "creme-brulee-2" for "Crème Brûlée #2". Accents are stripped, and each run
+// of anything other than ASCII letters and digits becomes a single hyphen.
+func (stringService) Slugify(_ context.Context, s string) (string, error) {
+	stripMarks := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)))
+	s, _, err := transform.String(stripMarks, s)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		ascii, ok := slugFolds[r]
+		if !ok && (r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+			ascii, ok = string(r), true
+		}
+		if ok {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteString(ascii)
+			continue
+		}
+		hyphen = true
+	}
+	if b.Len() == 0 {
+		return "", ErrEmpty
+	}
+	return b.String(), nil
+}
+
+// Mask replaces all but the last visible runes of s with the first rune of
+// mask, or with '*' if mask is empty, so "4111111111111111" with visible 4
+// becomes "************1111". visible is clamped to the length of s.
+func (stringService) Mask(_ context.Context, s string, visible int, mask string) (string, error) {
+	if s == "" {
+		return "", ErrEmpty
+	}
+	m := '*'
+	if mask != "" {
+		m, _ = utf8.DecodeRuneInString(mask)
+	}
+	r := []rune(s)
+	visible = min(max(visible, 0), len(r))
+	for i := range r[:len(r)-visible] {
+		r[i] = m
+	}
+	return string(r), nil
+}
+
+// LineCount returns the number of lines in s, which may end in "\n" or
+// "\r\n". A trailing newline ends the last line rather than starting an
+// empty one, so "a\nb\n" and "a\nb" both have two lines.
+func (stringService) LineCount(_ context.Context, s string) (int, error) {
+	if s == "" {
+		return 0, ErrEmpty
+	}
+	sc := bufio.NewScanner(strings.NewReader(s))
+	// The default limit of 64KB per line would fail on long lines that the
+	// service otherwise accepts.
+	sc.Buffer(nil, max(len(s), bufio.MaxScanTokenSize))
+	var n int
+	for sc.Scan() {
+		n++
+	}
+	return n, sc.Err()
+}
+
+// UppercaseStream copies src to dst in upper case, a chunk at a time, and
+// returns the number of bytes read from src. It stops early if ctx is done.
+func (stringService) UppercaseStream(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	return uppercaseStream(ctx, dst, src)
+}
+
+// scriptNames lists the scripts of unicode.Scripts in alphabetical order,
+// leaving out Common and Inherited, which are shared between scripts.
+var scriptNames = func() []string {
+	var names []string
+	for name := range unicode.Scripts {
+		if name != "Common" && name != "Inherited" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}()
+
+// maxPatternLength bounds the size of patterns accepted by RegexFind. Go's
+// regexp package runs in time linear in the input, so there is no
+// catastrophic backtracking to guard against, but compiling a huge pattern
+// still costs memory.
+const maxPatternLength = 1024
+
+// regexCache holds recently compiled patterns.
+var regexCache, _ = lru.New(256)
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > maxPatternLength {
+		return nil, ErrTooLong
+	}
+	if re, ok := regexCache.Get(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, ErrInvalidRegex
+	}
+	regexCache.Add(pattern, re)
+	return re, nil
+}
+
+// caseWords splits s into words at anything that isn't a letter or digit,
+// and at changes of case: "parseHTTPRequest_v2" gives parse, HTTP, Request
+// and v2.
+func caseWords(s string) []string {
+	var words []string
+	rs := []rune(s)
+	start := -1
+	for i, r := range rs {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(rs[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := rs[i-1]
+			// Split before an upper-case letter that follows a lower-case
+			// one or a digit, or that starts a word after an acronym.
+			nextLower := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(rs[start:i]))
+				start = i
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(rs[start:]))
+	}
+	return words
+}
+
+func upperFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+// soundexDigits gives the Soundex digit for each letter from A to Z, with
+// '0' for letters that aren't coded.
+const soundexDigits = "01230120022455012623010202"
+
+var normForms = map[string]norm.Form{
+	"NFC":  norm.NFC,
+	"NFD":  norm.NFD,
+	"NFKC": norm.NFKC,
+	"NFKD": norm.NFKD,
+}
+
+// slugFolds spells out the lower-case Latin letters that have no canonical
+// decomposition, so stripping marks alone can't reduce them to ASCII.
+var slugFolds = map[rune]string{
+	'æ': "ae",
+	'đ': "d",
+	'ð': "d",
+	'ħ': "h",
+	'ı': "i",
+	'ł': "l",
+	'ø': "o",
+	'œ': "oe",
+	'ß': "ss",
+	'þ': "th",
+}
+
+// ServiceError is an error that knows how it should be reported to callers:
+// a machine-readable Code, the HTTP Status to answer with, and a
+// human-readable Message.
+type ServiceError struct {
+	Code    string
+	Status  int
+	Message string
+}
+
+func (e *ServiceError) Error() string { return e.Message }
+
 // ErrEmpty is returned when an input string is empty.
-var ErrEmpty = errors.New("empty string")
+var ErrEmpty = &ServiceError{Code: "empty", Status: http.StatusBadRequest, Message: "empty string"}
+
+// ErrBatchTooLarge is returned when a batch request holds more items than
+// the server accepts.
+var ErrBatchTooLarge = &ServiceError{Code: "batch_too_large", Status: http.StatusRequestEntityTooLarge, Message: "batch too large"}
+
+// ErrEmptyPad is returned when padding is needed but no pad string was given.
+var ErrEmptyPad = &ServiceError{Code: "empty_pad", Status: http.StatusBadRequest, Message: "empty pad string"}
+
+// ErrInvalidBase64 is returned when decoding input that isn't valid standard
+// base64.
+var ErrInvalidBase64 = &ServiceError{Code: "invalid_base64", Status: http.StatusBadRequest, Message: "invalid base64"}
+
+// ErrUnknownAlgorithm is returned when asked for a hash function that isn't
+// supported.
+var ErrUnknownAlgorithm = &ServiceError{Code: "unknown_algorithm", Status: http.StatusBadRequest, Message: "unknown hash algorithm"}
+
+// ErrNegativeCount is returned when asked to repeat a string a negative
+// number of times.
+var ErrNegativeCount = &ServiceError{Code: "negative_count", Status: http.StatusBadRequest, Message: "negative count"}
+
+// ErrNegativeMax is returned when asked to truncate to a negative length.
+var ErrNegativeMax = &ServiceError{Code: "negative_max", Status: http.StatusBadRequest, Message: "negative max length"}
+
+// ErrUnknownForm is returned when asked for a Unicode normalization form
+// that isn't NFC, NFD, NFKC or NFKD.
+var ErrUnknownForm = &ServiceError{Code: "unknown_form", Status: http.StatusBadRequest, Message: "unknown normalization form"}
+
+// ErrNoLetters is returned when an input has no letters to encode.
+var ErrNoLetters = &ServiceError{Code: "no_letters", Status: http.StatusBadRequest, Message: "no letters to encode"}
+
+// ErrEmptySubstr is returned when asked to count occurrences of the empty
+// string.
+var ErrEmptySubstr = &ServiceError{Code: "empty_substr", Status: http.StatusBadRequest, Message: "empty substring"}
+
+// ErrUnknownCase is returned when asked for a case style that isn't snake,
+// camel, pascal or kebab.
+var ErrUnknownCase = &ServiceError{Code: "unknown_case", Status: http.StatusBadRequest, Message: "unknown case style"}
+
+// ErrInvalidRegex is returned when a pattern isn't a valid regular
+// expression.
+var ErrInvalidRegex = &ServiceError{Code: "invalid_regex", Status: http.StatusBadRequest, Message: "invalid regular expression"}
+
+// ErrInvalidURLEscape is returned when decoding input with a malformed
+// percent-escape.
+var ErrInvalidURLEscape = &ServiceError{Code: "invalid_url_escape", Status: http.StatusBadRequest, Message: "invalid URL escape"}
+
+// countErrors returns the number of non-nil errors in errs.
+func countErrors(errs []error) int {
+	var n int
+	for _, err := range errs {
+		if err != nil {
+			n++
+		}
+	}
+	return n
+}
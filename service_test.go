@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUppercase(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+		err  error
+	}{
+		{"normal", "hello", "HELLO", nil},
+		{"empty", "", "", ErrEmpty},
+		{"unicode", "grüß dich, ça va", "GRÜß DICH, ÇA VA", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stringService{}.Uppercase(context.Background(), tt.s)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Uppercase(%q) error = %v, want %v", tt.s, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Uppercase(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want Counts
+		err  error
+	}{
+		{"ascii", "hello", Counts{Bytes: 5, Runes: 5}, nil},
+		{"empty", "", Counts{}, ErrEmpty},
+		{"accented", "héllo", Counts{Bytes: 6, Runes: 5}, nil},
+		{"cjk", "日本語", Counts{Bytes: 9, Runes: 3}, nil},
+		{"emoji", "hi 👋", Counts{Bytes: 7, Runes: 4}, nil},
+		{"combining", "e\u0301", Counts{Bytes: 3, Runes: 2}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stringService{}.Count(context.Background(), tt.s)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Count(%q) error = %v, want %v", tt.s, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Count(%q) = %+v, want %+v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestStringService checks each of the remaining methods with a typical
+// input and, where the method has one, its error case.
+func TestStringService(t *testing.T) {
+	ctx := context.Background()
+	svc := stringService{}
+	tests := []struct {
+		name string
+		call func() (interface{}, error)
+		want interface{}
+		err  error
+	}{
+		{"Lowercase", func() (interface{}, error) { return svc.Lowercase(ctx, "HeLLo") }, "hello", nil},
+		{"Lowercase/empty", func() (interface{}, error) { return svc.Lowercase(ctx, "") }, "", ErrEmpty},
+		{"Reverse", func() (interface{}, error) { return svc.Reverse(ctx, "añb") }, "bña", nil},
+		{"Reverse/empty", func() (interface{}, error) { return svc.Reverse(ctx, "") }, "", ErrEmpty},
+		{"Trim/space", func() (interface{}, error) { return svc.Trim(ctx, "  hi\t", "") }, "hi", nil},
+		{"Trim/cutset", func() (interface{}, error) { return svc.Trim(ctx, "xxhixx", "x") }, "hi", nil},
+		{"Trim/empty", func() (interface{}, error) { return svc.Trim(ctx, "", "x") }, "", ErrEmpty},
+		{"Health", func() (interface{}, error) { return svc.Health(ctx) }, true, nil},
+		{"Version", func() (interface{}, error) { return svc.Version(ctx) }, BuildInfo{Version: version, GitCommit: gitCommit, BuildTime: buildTime}, nil},
+		{"WordCount", func() (interface{}, error) { return svc.WordCount(ctx, " one two\tthree ") }, 3, nil},
+		{"WordCount/blank", func() (interface{}, error) { return svc.WordCount(ctx, "   ") }, 0, ErrEmpty},
+		{"RuneCount", func() (interface{}, error) { return svc.RuneCount(ctx, "日本語") }, 3, nil},
+		{"RuneCount/empty", func() (interface{}, error) { return svc.RuneCount(ctx, "") }, 0, ErrEmpty},
+		{"Concat", func() (interface{}, error) { return svc.Concat(ctx, []string{"a", "b", "c"}, "-") }, "a-b-c", nil},
+		{"Concat/none", func() (interface{}, error) { return svc.Concat(ctx, nil, "-") }, "", ErrEmpty},
+		{"Replace", func() (interface{}, error) { return svc.Replace(ctx, "a.b.c", ".", "/", 1) }, "a/b.c", nil},
+		{"Replace/all", func() (interface{}, error) { return svc.Replace(ctx, "a.b.c", ".", "/", -1) }, "a/b/c", nil},
+		{"Replace/empty", func() (interface{}, error) { return svc.Replace(ctx, "", ".", "/", -1) }, "", ErrEmpty},
+		{"Contains", func() (interface{}, error) { return svc.Contains(ctx, "seafood", "foo") }, true, nil},
+		{"Contains/missing", func() (interface{}, error) { return svc.Contains(ctx, "seafood", "bar") }, false, nil},
+		{"Contains/empty", func() (interface{}, error) { return svc.Contains(ctx, "", "foo") }, false, ErrEmpty},
+		{"Split", func() (interface{}, error) { return svc.Split(ctx, "a,b,c", ",") }, []string{"a", "b", "c"}, nil},
+		{"Split/runes", func() (interface{}, error) { return svc.Split(ctx, "añ", "") }, []string{"a", "ñ"}, nil},
+		{"Split/empty", func() (interface{}, error) { return svc.Split(ctx, "", ",") }, []string(nil), ErrEmpty},
+		{"Title", func() (interface{}, error) { return svc.Title(ctx, "hello wide world") }, "Hello Wide World", nil},
+		{"Title/empty", func() (interface{}, error) { return svc.Title(ctx, "") }, "", ErrEmpty},
+		{"Pad/left", func() (interface{}, error) { return svc.Pad(ctx, "7", 3, "0", true) }, "007", nil},
+		{"Pad/right", func() (interface{}, error) { return svc.Pad(ctx, "ab", 5, "xy", false) }, "abxyx", nil},
+		{"Pad/wide enough", func() (interface{}, error) { return svc.Pad(ctx, "abc", 2, "", false) }, "abc", nil},
+		{"Pad/no pad", func() (interface{}, error) { return svc.Pad(ctx, "abc", 5, "", false) }, "", ErrEmptyPad},
+		{"Pad/empty", func() (interface{}, error) { return svc.Pad(ctx, "", 5, "x", false) }, "", ErrEmpty},
+		{"Capitalize", func() (interface{}, error) { return svc.Capitalize(ctx, "élan vital") }, "Élan vital", nil},
+		{"Capitalize/empty", func() (interface{}, error) { return svc.Capitalize(ctx, "") }, "", ErrEmpty},
+		{"Base64Encode", func() (interface{}, error) { return svc.Base64Encode(ctx, "hi?") }, "aGk/", nil},
+		{"Base64Encode/empty", func() (interface{}, error) { return svc.Base64Encode(ctx, "") }, "", ErrEmpty},
+		{"Base64Decode", func() (interface{}, error) { return svc.Base64Decode(ctx, "aGk/") }, "hi?", nil},
+		{"Base64Decode/invalid", func() (interface{}, error) { return svc.Base64Decode(ctx, "not base64!") }, "", ErrInvalidBase64},
+		{"Hash/sha256", func() (interface{}, error) { return svc.Hash(ctx, "abc", "sha256") }, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", nil},
+		{"Hash/sha1", func() (interface{}, error) { return svc.Hash(ctx, "abc", "sha1") }, "a9993e364706816aba3e25717850c26c9cd0d89d", nil},
+		{"Hash/md5", func() (interface{}, error) { return svc.Hash(ctx, "abc", "md5") }, "900150983cd24fb0d6963f7d28e17f72", nil},
+		{"Hash/unknown", func() (interface{}, error) { return svc.Hash(ctx, "abc", "crc32") }, "", ErrUnknownAlgorithm},
+		{"UppercaseExcept", func() (interface{}, error) { return svc.UppercaseExcept(ctx, "hello", "l") }, "HEllO", nil},
+		{"UppercaseExcept/empty", func() (interface{}, error) { return svc.UppercaseExcept(ctx, "", "l") }, "", ErrEmpty},
+		{"CharFrequency", func() (interface{}, error) { return svc.CharFrequency(ctx, "abcaé") }, map[string]int{"a": 2, "b": 1, "c": 1, "é": 1}, nil},
+		{"CharFrequency/empty", func() (interface{}, error) { return svc.CharFrequency(ctx, "") }, map[string]int(nil), ErrEmpty},
+		{"Distance", func() (interface{}, error) { return svc.Distance(ctx, "flaw", "lawn") }, 2, nil},
+		{"Repeat", func() (interface{}, error) { return svc.Repeat(ctx, "ab", 3) }, "ababab", nil},
+		{"Repeat/zero", func() (interface{}, error) { return svc.Repeat(ctx, "ab", 0) }, "", nil},
+		{"Repeat/negative", func() (interface{}, error) { return svc.Repeat(ctx, "ab", -1) }, "", ErrNegativeCount},
+		{"Truncate", func() (interface{}, error) { return svc.Truncate(ctx, "héllo world", 5, false) }, "héllo", nil},
+		{"Truncate/ellipsis", func() (interface{}, error) { return svc.Truncate(ctx, "héllo world", 5, true) }, "héll…", nil},
+		{"Truncate/short", func() (interface{}, error) { return svc.Truncate(ctx, "hi", 5, true) }, "hi", nil},
+		{"Truncate/negative", func() (interface{}, error) { return svc.Truncate(ctx, "hi", -1, false) }, "", ErrNegativeMax},
+		{"IsPalindrome", func() (interface{}, error) { return svc.IsPalindrome(ctx, "Racecar", false) }, true, nil},
+		{"IsPalindrome/strip", func() (interface{}, error) { return svc.IsPalindrome(ctx, "A man, a plan, a canal: Panama", true) }, true, nil},
+		{"IsPalindrome/no strip", func() (interface{}, error) { return svc.IsPalindrome(ctx, "A man, a plan, a canal: Panama", false) }, false, nil},
+		{"IsPalindrome/empty", func() (interface{}, error) { return svc.IsPalindrome(ctx, "", false) }, false, ErrEmpty},
+		{"Normalize/NFD", func() (interface{}, error) { return svc.Normalize(ctx, "\u00e9", "nfd") }, "e\u0301", nil},
+		{"Normalize/NFC", func() (interface{}, error) { return svc.Normalize(ctx, "e\u0301", "NFC") }, "\u00e9", nil},
+		{"Normalize/unknown", func() (interface{}, error) { return svc.Normalize(ctx, "e", "NFX") }, "", ErrUnknownForm},
+		{"Soundex", func() (interface{}, error) { return svc.Soundex(ctx, "Robert") }, "R163", nil},
+		{"Soundex/empty", func() (interface{}, error) { return svc.Soundex(ctx, "") }, "", ErrEmpty},
+		{"CountSubstr", func() (interface{}, error) { return svc.CountSubstr(ctx, "cheese", "e") }, 3, nil},
+		{"CountSubstr/empty substr", func() (interface{}, error) { return svc.CountSubstr(ctx, "cheese", "") }, 0, ErrEmptySubstr},
+		{"ConvertCase", func() (interface{}, error) { return svc.ConvertCase(ctx, "helloWorld", "snake") }, "hello_world", nil},
+		{"ConvertCase/unknown", func() (interface{}, error) { return svc.ConvertCase(ctx, "helloWorld", "train") }, "", ErrUnknownCase},
+		{"RegexFind", func() (interface{}, error) { return svc.RegexFind(ctx, "a1b22c333", `\d+`) }, []string{"1", "22", "333"}, nil},
+		{"RegexFind/no match", func() (interface{}, error) { return svc.RegexFind(ctx, "abc", `\d+`) }, []string{}, nil},
+		{"RegexFind/invalid", func() (interface{}, error) { return svc.RegexFind(ctx, "abc", `(`) }, []string(nil), ErrInvalidRegex},
+		{"RegexFind/long pattern", func() (interface{}, error) { return svc.RegexFind(ctx, "abc", strings.Repeat("a", maxPatternLength+1)) }, []string(nil), ErrTooLong},
+		{"CollapseSpaces", func() (interface{}, error) { return svc.CollapseSpaces(ctx, "  a \t b\n\nc ") }, "a b c", nil},
+		{"CollapseSpaces/blank", func() (interface{}, error) { return svc.CollapseSpaces(ctx, " \t\n") }, "", ErrEmpty},
+		{"DetectScript/Latin", func() (interface{}, error) { return svc.DetectScript(ctx, "hello, мир!") }, "Latin", nil},
+		{"DetectScript/Cyrillic", func() (interface{}, error) { return svc.DetectScript(ctx, "привет, world") }, "Cyrillic", nil},
+		{"DetectScript/Common", func() (interface{}, error) { return svc.DetectScript(ctx, "1234 !?") }, "Common", nil},
+		{"URLEncode", func() (interface{}, error) { return svc.URLEncode(ctx, "a b&c=d") }, "a+b%26c%3Dd", nil},
+		{"URLDecode", func() (interface{}, error) { return svc.URLDecode(ctx, "a+b%26c%3Dd") }, "a b&c=d", nil},
+		{"URLDecode/invalid", func() (interface{}, error) { return svc.URLDecode(ctx, "100%") }, "", ErrInvalidURLEscape},
+		{"Slugify", func() (interface{}, error) { return svc.Slugify(ctx, "Hello World") }, "hello-world", nil},
+		{"Slugify/empty", func() (interface{}, error) { return svc.Slugify(ctx, "") }, "", ErrEmpty},
+		{"Mask", func() (interface{}, error) { return svc.Mask(ctx, "4111111111111111", 4, "") }, "************1111", nil},
+		{"Mask/rune", func() (interface{}, error) { return svc.Mask(ctx, "sécret", 2, "•") }, "••••et", nil},
+		{"Mask/clamped", func() (interface{}, error) { return svc.Mask(ctx, "abc", 10, "#") }, "abc", nil},
+		{"Mask/empty", func() (interface{}, error) { return svc.Mask(ctx, "", 4, "") }, "", ErrEmpty},
+		{"LineCount", func() (interface{}, error) { return svc.LineCount(ctx, "a\nb\n") }, 2, nil},
+		{"LineCount/crlf", func() (interface{}, error) { return svc.LineCount(ctx, "a\r\nb\r\nc") }, 3, nil},
+		{"LineCount/empty", func() (interface{}, error) { return svc.LineCount(ctx, "") }, 0, ErrEmpty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"same", "same", 0},
+		{"café", "cafe", 1},
+		{"日本語", "日本", 1},
+		{"naïve", "naive", 1},
+	}
+	for _, tt := range tests {
+		got, err := stringService{}.Distance(context.Background(), tt.a, tt.b)
+		if err != nil {
+			t.Fatalf("Distance(%q, %q) error = %v", tt.a, tt.b, err)
+		}
+		if got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSoundex(t *testing.T) {
+	tests := []struct {
+		s, want string
+		err     error
+	}{
+		{"Robert", "R163", nil},
+		{"Rupert", "R163", nil},
+		{"Rubin", "R150", nil},
+		{"Ashcraft", "A261", nil},
+		{"Ashcroft", "A261", nil},
+		{"Tymczak", "T522", nil},
+		{"Pfister", "P236", nil},
+		{"Honeyman", "H555", nil},
+		{"Lee", "L000", nil},
+		{"robert", "R163", nil},
+		{"Müller", "M460", nil},
+		{"Éric", "E620", nil},
+		{"", "", ErrEmpty},
+		{"123 ☃", "", ErrNoLetters},
+	}
+	for _, tt := range tests {
+		got, err := stringService{}.Soundex(context.Background(), tt.s)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("Soundex(%q) error = %v, want %v", tt.s, err, tt.err)
+		}
+		if got != tt.want {
+			t.Errorf("Soundex(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+// TestCountSubstr pins down that occurrences are counted without overlap:
+// "aa" can be found at three positions in "aaaa", but only two of them fit
+// side by side.
+func TestCountSubstr(t *testing.T) {
+	tests := []struct {
+		s, substr   string
+		want        int
+		overlapping int
+	}{
+		{"aaaa", "aa", 2, 3},
+		{"abababa", "aba", 2, 3},
+		{"cheese", "e", 3, 3},
+		{"banana", "nan", 1, 1},
+		{"héhéhé", "hé", 3, 3},
+		{"abc", "d", 0, 0},
+	}
+	for _, tt := range tests {
+		got, err := stringService{}.CountSubstr(context.Background(), tt.s, tt.substr)
+		if err != nil {
+			t.Fatalf("CountSubstr(%q, %q) error = %v", tt.s, tt.substr, err)
+		}
+		if got != tt.want {
+			t.Errorf("CountSubstr(%q, %q) = %d, want %d", tt.s, tt.substr, got, tt.want)
+		}
+		if n := countOverlapping(tt.s, tt.substr); n != tt.overlapping {
+			t.Errorf("countOverlapping(%q, %q) = %d, want %d", tt.s, tt.substr, n, tt.overlapping)
+		}
+	}
+}
+
+// countOverlapping counts every position at which substr starts in s.
+func countOverlapping(s, substr string) int {
+	var n int
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.HasPrefix(s[i:], substr) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestConvertCase(t *testing.T) {
+	tests := []struct {
+		s, to, want string
+	}{
+		{"HTTPServer_id-value", "snake", "http_server_id_value"},
+		{"HTTPServer_id-value", "kebab", "http-server-id-value"},
+		{"HTTPServer_id-value", "camel", "httpServerIdValue"},
+		{"HTTPServer_id-value", "pascal", "HttpServerIdValue"},
+		{"parseHTTPRequest_v2", "snake", "parse_http_request_v2"},
+		{"user42Name", "kebab", "user42-name"},
+		{"  leading and trailing  ", "pascal", "LeadingAndTrailing"},
+		{"already_snake", "SNAKE", "already_snake"},
+		{"ÉcoleNormale", "snake", "école_normale"},
+	}
+	for _, tt := range tests {
+		got, err := stringService{}.ConvertCase(context.Background(), tt.s, tt.to)
+		if err != nil {
+			t.Fatalf("ConvertCase(%q, %q) error = %v", tt.s, tt.to, err)
+		}
+		if got != tt.want {
+			t.Errorf("ConvertCase(%q, %q) = %q, want %q", tt.s, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		s, want string
+		err     error
+	}{
+		{"Hello, World!", "hello-world", nil},
+		{"Crème Brûlée #2", "creme-brulee-2", nil},
+		{"Ærøskøbing Straße", "aeroskobing-strasse", nil},
+		{"Łódź", "lodz", nil},
+		{"naïve café", "naive-cafe", nil},
+		{"  --Hello,   World!--  ", "hello-world", nil},
+		{"a--b__c..d", "a-b-c-d", nil},
+		{"C++ & Go", "c-go", nil},
+		{"日本語 text", "text", nil},
+		{"!!!", "", ErrEmpty},
+		{"日本語", "", ErrEmpty},
+		{"", "", ErrEmpty},
+	}
+	for _, tt := range tests {
+		got, err := stringService{}.Slugify(context.Background(), tt.s)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("Slugify(%q) error = %v, want %v", tt.s, err, tt.err)
+		}
+		if got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestUppercaseBatch(t *testing.T) {
+	vs, errs := stringService{}.UppercaseBatch(context.Background(), []string{"a", "", "é"})
+	if want := []string{"A", "", "É"}; !reflect.DeepEqual(vs, want) {
+		t.Errorf("values = %q, want %q", vs, want)
+	}
+	if want := []error{nil, ErrEmpty, nil}; !reflect.DeepEqual(errs, want) {
+		t.Errorf("errors = %v, want %v", errs, want)
+	}
+}
+
+func TestUppercaseStream(t *testing.T) {
+	var b strings.Builder
+	n, err := stringService{}.UppercaseStream(context.Background(), &b, strings.NewReader("stream me"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 9 || b.String() != "STREAM ME" {
+		t.Errorf("UppercaseStream = %d, %q; want 9, %q", n, b.String(), "STREAM ME")
+	}
+}
+
+// The benchmarks give a baseline for the cost of the core operations. Run
+// them with allocation counts using
+//
+//	go test -run '^$' -bench . -benchmem
+func BenchmarkUppercase(b *testing.B) {
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		stringService{}.Uppercase(ctx, "the quick brown fox jumps over the lazy dog")
+	}
+}
+
+func BenchmarkCount(b *testing.B) {
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		stringService{}.Count(ctx, "the quick brown fox jumps over the lazy dög")
+	}
+}
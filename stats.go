@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+// statsResponse is a summary of the service's counters for consumers that
+// don't speak the Prometheus exposition format.
+type statsResponse struct {
+	Requests         float64                       `json:"requests"`
+	RequestsByMethod map[string]float64            `json:"requests_by_method"`
+	Errors           map[string]map[string]float64 `json:"errors"`
+	CacheHits        map[string]float64            `json:"cache_hits"`
+}
+
+// statsHandler serves a JSON snapshot of the request, error and cache hit
+// counters gathered from g, so that it always agrees with /metrics.
+// Errors are keyed by method and then by error type.
+func statsHandler(g stdprometheus.Gatherer, namespace, subsystem string) http.Handler {
+	var (
+		requestCount = stdprometheus.BuildFQName(namespace, subsystem, "request_count")
+		errorCount   = stdprometheus.BuildFQName(namespace, subsystem, "errors_total")
+		cacheHits    = stdprometheus.BuildFQName(namespace, subsystem, "cache_hits_total")
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mfs, err := g.Gather()
+		if err != nil {
+			encodeError(r.Context(), err, w)
+			return
+		}
+		resp := statsResponse{
+			RequestsByMethod: map[string]float64{},
+			Errors:           map[string]map[string]float64{},
+			CacheHits:        map[string]float64{},
+		}
+		for _, mf := range mfs {
+			for _, m := range mf.GetMetric() {
+				labels := map[string]string{}
+				for _, lp := range m.GetLabel() {
+					labels[lp.GetName()] = lp.GetValue()
+				}
+				v := m.GetCounter().GetValue()
+				switch mf.GetName() {
+				case requestCount:
+					resp.Requests += v
+					resp.RequestsByMethod[labels["method"]] += v
+				case errorCount:
+					method := labels["method"]
+					if resp.Errors[method] == nil {
+						resp.Errors[method] = map[string]float64{}
+					}
+					resp.Errors[method][labels["error_type"]] += v
+				case cacheHits:
+					resp.CacheHits[labels["method"]] += v
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(resp)
+	})
+}
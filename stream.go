@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	httptransport "github.com/go-kit/kit/transport/http"
+)
+
+// streamChunkSize is how much of the body is read and uppercased at a time.
+const streamChunkSize = 32 * 1024
+
+// uppercaseStreamHandler uppercases a raw text request body through
+// svc.UppercaseStream and writes the result back as it goes, without
+// buffering either or going through JSON. The shared service middlewares
+// (rate limiting, metrics and logging) apply as for any other method, but
+// it is a plain http.Handler, so it runs the before hooks and checks the
+// API key itself. It is served outside timeoutHandler and maxBodyHandler,
+// which would buffer the response and cut the body off far too early;
+// instead the body may be up to maxBytes long and the whole stream must
+// finish within timeout.
+func uppercaseStreamHandler(svc StringService, keys []string, maxBytes int64, timeout time.Duration, logger log.Logger, before ...httptransport.RequestFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		ctx := r.Context()
+		for _, f := range before {
+			ctx = f(ctx, r)
+		}
+		echoRequestID(ctx, w)
+		if len(keys) > 0 && !validAPIKey(r.Header.Get(apiKeyHeader), keys) {
+			encodeError(ctx, ErrUnauthorized, w)
+			return
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		// The server's read timeout is sized for ordinary requests, so the
+		// stream gets its own deadline in its place.
+		rc := http.NewResponseController(w)
+		deadline := time.Now().Add(timeout)
+		if err := rc.SetReadDeadline(deadline); err != nil && err != http.ErrNotSupported {
+			level.Error(logger).Log("method", "uppercase_stream", "during", "SetReadDeadline", "err", err)
+		}
+		if err := rc.SetWriteDeadline(deadline); err != nil && err != http.ErrNotSupported {
+			level.Error(logger).Log("method", "uppercase_stream", "during", "SetWriteDeadline", "err", err)
+		}
+		// HTTP/1.1 responses are half duplex by default: once output has
+		// been flushed, the rest of the body can no longer be read.
+		if err := rc.EnableFullDuplex(); err != nil && err != http.ErrNotSupported {
+			level.Error(logger).Log("method", "uppercase_stream", "during", "EnableFullDuplex", "err", err)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fw := &flushWriter{w: w, flush: rc.Flush}
+		body := streamBody{http.MaxBytesReader(w, r.Body, maxBytes)}
+		if _, err := svc.UppercaseStream(ctx, fw, body); err != nil && !fw.wrote {
+			// Once output has been sent, the status line has gone out too
+			// and all we can do is stop; the error is already logged.
+			encodeError(ctx, err, w)
+		}
+	})
+}
+
+// flushWriter flushes each write through to the client and remembers
+// whether anything has been written.
+type flushWriter struct {
+	w     io.Writer
+	flush func() error
+	wrote bool
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	fw.wrote = true
+	n, err := fw.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, fw.flush()
+}
+
+// streamBody reports a body cut off by http.MaxBytesReader as ErrTooLong,
+// the same as the JSON decoders do.
+type streamBody struct {
+	io.Reader
+}
+
+func (b streamBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		err = ErrTooLong
+	}
+	return n, err
+}
+
+// uppercaseStream copies r to w in upper case and returns the number of
+// bytes read from r. A rune split across two reads is held back until it is
+// complete, so multi-byte characters are never cut in half.
+func uppercaseStream(ctx context.Context, w io.Writer, r io.Reader) (int64, error) {
+	buf := make([]byte, streamChunkSize)
+	var (
+		held  int
+		total int64
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		n, err := r.Read(buf[held:])
+		total += int64(n)
+		n += held
+		end := n
+		if err == nil {
+			end = completeRunes(buf[:n])
+		}
+		if end > 0 {
+			if _, werr := w.Write(bytes.ToUpper(buf[:end])); werr != nil {
+				return total, werr
+			}
+		}
+		held = copy(buf, buf[end:n])
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// completeRunes returns the length of the longest prefix of p that does not
+// end in the middle of a UTF-8 encoded rune.
+func completeRunes(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if utf8.FullRune(p[i:]) {
+				return len(p)
+			}
+			return i
+		}
+	}
+	return len(p)
+}
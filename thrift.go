@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"github.com/AndrewSC208/StringService/thrift/gen-go/stringsvc"
+)
+
+// thriftServer makes the Uppercase and Count endpoints available as a Thrift
+// StringService. Thrift calls carry no headers, so they can't present an
+// API key and are rejected when keys are configured.
+type thriftServer struct {
+	uppercase endpoint.Endpoint
+	count     endpoint.Endpoint
+}
+
+func newThriftServer(uppercase, count endpoint.Endpoint) stringsvc.StringService {
+	return &thriftServer{uppercase, count}
+}
+
+func (s *thriftServer) Uppercase(ctx context.Context, str string) (*stringsvc.UppercaseReply, error) {
+	response, err := s.uppercase(ctx, uppercaseRequest{S: str})
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(uppercaseResponse)
+	return &stringsvc.UppercaseReply{V: resp.V}, nil
+}
+
+func (s *thriftServer) Count(ctx context.Context, str string) (*stringsvc.CountReply, error) {
+	response, err := s.count(ctx, countRequest{S: str})
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(countResponse)
+	return &stringsvc.CountReply{V: int64(resp.V)}, nil
+}
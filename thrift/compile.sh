@@ -0,0 +1,9 @@
+#!/usr/bin/env sh
+
+# The bindings are generated with thriftgo, a Go implementation of the
+# Thrift compiler that emits code for the Apache Thrift Go library:
+#  go get github.com/cloudwego/thriftgo
+#
+# See also https://thrift.apache.org/tutorial/go.
+
+thriftgo -r -o gen-go -g "go:naming_style=apache,package_prefix=github.com/AndrewSC208/StringService/thrift/gen-go" stringsvc.thrift
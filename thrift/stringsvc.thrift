@@ -0,0 +1,21 @@
+namespace go stringsvc
+
+// The Uppercase reply contains the result of the transformation. Errors are
+// reported as Thrift application exceptions.
+struct UppercaseReply {
+	1: string v
+}
+
+// The Count reply contains the number of bytes in the string.
+struct CountReply {
+	1: i64 v
+}
+
+// The StringService service definition.
+service StringService {
+	// Uppercases a string.
+	UppercaseReply Uppercase(1: string s)
+
+	// Counts the bytes in a string.
+	CountReply Count(1: string s)
+}
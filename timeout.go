@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+)
+
+// ErrTimeout is the error reported when a request takes longer than the
+// server allows.
+var ErrTimeout = &ServiceError{Code: "timeout", Status: http.StatusServiceUnavailable, Message: "request timed out"}
+
+// requestTimeoutHeader carries the caller's time budget for a request, in
+// milliseconds.
+const requestTimeoutHeader = "X-Request-Timeout"
+
+// populateDeadline returns a ServerBefore hook that sets a deadline on the
+// context from the X-Request-Timeout header, so that endpoints and anything
+// they call give up once the caller's budget is spent. Budgets longer than
+// max are cut down to max. A missing or malformed header leaves the context
+// as it is. cancelDeadline must be registered as a ServerFinalizer alongside
+// it.
+func populateDeadline(max time.Duration) httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		ms, err := strconv.ParseInt(r.Header.Get(requestTimeoutHeader), 10, 64)
+		if err != nil || ms <= 0 {
+			return ctx
+		}
+		d := max
+		if ms < int64(max/time.Millisecond) {
+			d = time.Duration(ms) * time.Millisecond
+		}
+		ctx, cancel := context.WithTimeout(ctx, d)
+		return context.WithValue(ctx, deadlineCancelContextKey, cancel)
+	}
+}
+
+// cancelDeadline is a ServerFinalizer that releases the deadline set by
+// populateDeadline.
+func cancelDeadline(ctx context.Context, _ int, _ *http.Request) {
+	if cancel, ok := ctx.Value(deadlineCancelContextKey).(context.CancelFunc); ok {
+		cancel()
+	}
+}
+
+// timeoutHandler bounds the time spent serving each request. Requests that
+// take longer than d are answered with a 503 and ErrTimeout, encoded the
+// same way as encodeError would.
+func timeoutHandler(h http.Handler, d time.Duration) http.Handler {
+	body, _ := json.Marshal(newErrorResponse(ErrTimeout))
+	th := http.TimeoutHandler(h, d, string(body))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		th.ServeHTTP(timeoutWriter{w}, r)
+	})
+}
+
+// timeoutWriter labels the message http.TimeoutHandler writes on a timeout
+// as JSON. The handler writes it as a 503 without a Content-Type; responses
+// from handlers that finished in time keep the headers they set.
+type timeoutWriter struct {
+	http.ResponseWriter
+}
+
+func (w timeoutWriter) WriteHeader(code int) {
+	if code == http.StatusServiceUnavailable && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
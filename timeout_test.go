@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeoutHandler(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	rec := httptest.NewRecorder()
+	timeoutHandler(slow, 10*time.Millisecond).ServeHTTP(rec, httptest.NewRequest("POST", "/uppercase", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got, want := rec.Header().Get("Content-Type"), "application/json; charset=utf-8"; got != want {
+		t.Errorf("Content-Type = %q, want %q", got, want)
+	}
+	var resp errorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if want := (errorResponse{Error: ErrTimeout.Message, Code: ErrTimeout.Code}); resp.Error != want.Error || resp.Code != want.Code {
+		t.Errorf("body = %+v, want %+v", resp, want)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	kitzipkin "github.com/go-kit/kit/tracing/zipkin"
+	"github.com/openzipkin/zipkin-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingMiddleware wraps an endpoint in a span named after the method. The
+// span records the length of the input string and whether the call failed.
+func tracingMiddleware(tracer trace.Tracer, method string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			ctx, span := tracer.Start(ctx, method)
+			defer func() {
+				span.SetAttributes(attribute.Bool("error", err != nil))
+				if err != nil {
+					span.RecordError(err)
+					span.SetStatus(codes.Error, err.Error())
+				}
+				span.End()
+			}()
+
+			if n, ok := inputLength(request); ok {
+				span.SetAttributes(attribute.Int("input.length", n))
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// zipkinTracingMiddleware is the Zipkin counterpart of tracingMiddleware:
+// it wraps an endpoint in a span named after the method and tags the span
+// with the error, if any.
+func zipkinTracingMiddleware(tracer *zipkin.Tracer, method string) endpoint.Middleware {
+	tagError := func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			response, err := next(ctx, request)
+			if span := zipkin.SpanFromContext(ctx); span != nil && err != nil {
+				zipkin.TagError.Set(span, err.Error())
+			}
+			return response, err
+		}
+	}
+	return endpoint.Chain(kitzipkin.TraceEndpoint(tracer, method), tagError)
+}
+
+// extractTraceContext is a ServerBefore hook that continues a trace started
+// by the caller, as described by the W3C traceparent header.
+func extractTraceContext(ctx context.Context, r *http.Request) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+}
+
+func inputLength(request interface{}) (int, bool) {
+	switch req := request.(type) {
+	case uppercaseRequest:
+		return len(req.S), true
+	case countRequest:
+		return len(req.S), true
+	}
+	return 0, false
+}
@@ -3,63 +3,1417 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/metrics"
+	httptransport "github.com/go-kit/kit/transport/http"
 )
 
 func makeUppercaseEndpoint(svc StringService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		req := request.(uppercaseRequest)
-		v, err := svc.Uppercase(req.S)
+		if req.ValidateOnly {
+			ctx = withValidateOnly(ctx)
+		}
+		v, err := svc.Uppercase(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return uppercaseResponse{v}, nil
+	}
+}
+
+func makeLowercaseEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(lowercaseRequest)
+		v, err := svc.Lowercase(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return lowercaseResponse{v}, nil
+	}
+}
+
+func makeCountEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(countRequest)
+		if req.ValidateOnly {
+			ctx = withValidateOnly(ctx)
+		}
+		c, err := svc.Count(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return countResponse{V: c.Bytes, Bytes: c.Bytes, Runes: c.Runes}, nil
+	}
+}
+
+func makeReverseEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(reverseRequest)
+		v, err := svc.Reverse(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return reverseResponse{v}, nil
+	}
+}
+
+func makeTrimEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(trimRequest)
+		v, err := svc.Trim(ctx, req.S, req.Cutset)
+		if err != nil {
+			return nil, err
+		}
+		return trimResponse{v}, nil
+	}
+}
+
+func makeHealthEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		healthy, err := svc.Health(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !healthy {
+			return healthResponse{"unavailable"}, nil
+		}
+		return healthResponse{"ok"}, nil
+	}
+}
+
+func makeVersionEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		info, err := svc.Version(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return versionResponse{info.Version, info.GitCommit, info.BuildTime}, nil
+	}
+}
+
+func makeUppercaseBatchEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(uppercaseBatchRequest)
+		vs, errs := svc.UppercaseBatch(ctx, req.SS)
+		results := make([]batchResult, len(vs))
+		for i, err := range errs {
+			if isCallError(err) {
+				return nil, err
+			}
+			if err != nil {
+				results[i] = batchResult{vs[i], err.Error()}
+				continue
+			}
+			results[i] = batchResult{vs[i], ""}
+		}
+		return uppercaseBatchResponse{results}, nil
+	}
+}
+
+// isCallError reports whether err rejects a whole call rather than one item
+// of a batch: the caller isn't authorized or is over its rate limit, the
+// service can't take the call right now, or the caller gave up on it.
+func isCallError(err error) bool {
+	if isContextError(err) {
+		return true
+	}
+	var se *ServiceError
+	if !errors.As(err, &se) {
+		return false
+	}
+	switch se.Status {
+	case http.StatusUnauthorized, http.StatusTooManyRequests:
+		return true
+	}
+	return se.Status >= http.StatusInternalServerError
+}
+
+func makeWordCountEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(wordCountRequest)
+		v, err := svc.WordCount(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return wordCountResponse{v}, nil
+	}
+}
+
+func makeRuneCountEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(runeCountRequest)
+		v, err := svc.RuneCount(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return runeCountResponse{v}, nil
+	}
+}
+
+func makeConcatEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(concatRequest)
+		v, err := svc.Concat(ctx, req.SS, req.Sep)
+		if err != nil {
+			return nil, err
+		}
+		return concatResponse{v}, nil
+	}
+}
+
+func makeReplaceEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(replaceRequest)
+		v, err := svc.Replace(ctx, req.S, req.Old, req.New, req.N)
+		if err != nil {
+			return nil, err
+		}
+		return replaceResponse{v}, nil
+	}
+}
+
+func makeContainsEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(containsRequest)
+		v, err := svc.Contains(ctx, req.S, req.Substr)
+		if err != nil {
+			return nil, err
+		}
+		return containsResponse{v}, nil
+	}
+}
+
+func makeSplitEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(splitRequest)
+		parts, err := svc.Split(ctx, req.S, req.Sep)
+		if err != nil {
+			return nil, err
+		}
+		return splitResponse{parts}, nil
+	}
+}
+
+func makeTitleEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(titleRequest)
+		v, err := svc.Title(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return titleResponse{v}, nil
+	}
+}
+
+func makePadEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(padRequest)
+		v, err := svc.Pad(ctx, req.S, req.Width, req.Pad, req.Left)
+		if err != nil {
+			return nil, err
+		}
+		return padResponse{v}, nil
+	}
+}
+
+func makeCapitalizeEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(capitalizeRequest)
+		v, err := svc.Capitalize(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return capitalizeResponse{v}, nil
+	}
+}
+
+func makeBase64EncodeEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(base64EncodeRequest)
+		v, err := svc.Base64Encode(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return base64EncodeResponse{v}, nil
+	}
+}
+
+func makeBase64DecodeEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(base64DecodeRequest)
+		v, err := svc.Base64Decode(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return base64DecodeResponse{v}, nil
+	}
+}
+
+func makeHashEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(hashRequest)
+		v, err := svc.Hash(ctx, req.S, req.Algo)
+		if err != nil {
+			return nil, err
+		}
+		return hashResponse{v}, nil
+	}
+}
+
+func makeUppercaseExceptEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(uppercaseExceptRequest)
+		v, err := svc.UppercaseExcept(ctx, req.S, req.Except)
+		if err != nil {
+			return nil, err
+		}
+		return uppercaseExceptResponse{v}, nil
+	}
+}
+
+func makeCharFrequencyEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(charFrequencyRequest)
+		v, err := svc.CharFrequency(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return charFrequencyResponse{v}, nil
+	}
+}
+
+func makeDistanceEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(distanceRequest)
+		v, err := svc.Distance(ctx, req.A, req.B)
+		if err != nil {
+			return nil, err
+		}
+		return distanceResponse{v}, nil
+	}
+}
+
+func makeRepeatEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(repeatRequest)
+		v, err := svc.Repeat(ctx, req.S, req.Count)
+		if err != nil {
+			return nil, err
+		}
+		return repeatResponse{v}, nil
+	}
+}
+
+func makeTruncateEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(truncateRequest)
+		v, err := svc.Truncate(ctx, req.S, req.Max, req.Ellipsis)
+		if err != nil {
+			return nil, err
+		}
+		return truncateResponse{v}, nil
+	}
+}
+
+func makeIsPalindromeEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(isPalindromeRequest)
+		v, err := svc.IsPalindrome(ctx, req.S, req.Strip)
+		if err != nil {
+			return nil, err
+		}
+		return isPalindromeResponse{v}, nil
+	}
+}
+
+func makeNormalizeEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(normalizeRequest)
+		v, err := svc.Normalize(ctx, req.S, req.Form)
+		if err != nil {
+			return nil, err
+		}
+		return normalizeResponse{v}, nil
+	}
+}
+
+func makeSoundexEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(soundexRequest)
+		v, err := svc.Soundex(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return soundexResponse{v}, nil
+	}
+}
+
+func makeCountSubstrEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(countSubstrRequest)
+		v, err := svc.CountSubstr(ctx, req.S, req.Substr)
 		if err != nil {
-			return uppercaseResponse{v, err.Error()}, nil
+			return nil, err
 		}
-		return uppercaseResponse{v, ""}, nil
+		return countSubstrResponse{v}, nil
+	}
+}
+
+func makeConvertCaseEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(convertCaseRequest)
+		v, err := svc.ConvertCase(ctx, req.S, req.To)
+		if err != nil {
+			return nil, err
+		}
+		return convertCaseResponse{v}, nil
+	}
+}
+
+func makeRegexFindEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(regexFindRequest)
+		v, err := svc.RegexFind(ctx, req.S, req.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return regexFindResponse{v}, nil
+	}
+}
+
+func makeCollapseSpacesEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(collapseSpacesRequest)
+		v, err := svc.CollapseSpaces(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return collapseSpacesResponse{v}, nil
+	}
+}
+
+func makeDetectScriptEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(detectScriptRequest)
+		v, err := svc.DetectScript(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return detectScriptResponse{v}, nil
+	}
+}
+
+func makeURLEncodeEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(urlEncodeRequest)
+		v, err := svc.URLEncode(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return urlEncodeResponse{v}, nil
+	}
+}
+
+func makeURLDecodeEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(urlDecodeRequest)
+		v, err := svc.URLDecode(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return urlDecodeResponse{v}, nil
+	}
+}
+
+func makeSlugifyEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(slugifyRequest)
+		v, err := svc.Slugify(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return slugifyResponse{v}, nil
+	}
+}
+
+func makeMaskEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(maskRequest)
+		v, err := svc.Mask(ctx, req.S, req.Visible, req.Mask)
+		if err != nil {
+			return nil, err
+		}
+		return maskResponse{v}, nil
+	}
+}
+
+func makeLineCountEndpoint(svc StringService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := request.(lineCountRequest)
+		v, err := svc.LineCount(ctx, req.S)
+		if err != nil {
+			return nil, err
+		}
+		return lineCountResponse{v}, nil
+	}
+}
+
+// ErrBadRequest is returned by the decoders when the request body isn't
+// valid JSON for the endpoint.
+var ErrBadRequest = &ServiceError{Code: "bad_request", Status: http.StatusBadRequest, Message: "invalid JSON body"}
+
+// decodeError maps an error from decoding a request body to the error
+// reported to the caller: ErrTooLong if the body was cut off by
+// maxBodyHandler, and ErrBadRequest otherwise.
+func decodeError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return ErrTooLong
+	}
+	return ErrBadRequest
+}
+
+// countDecodeErrors wraps dec so that each request it fails to decode is
+// counted in decodeErrors, labeled by path, and logged.
+func countDecodeErrors(dec httptransport.DecodeRequestFunc, decodeErrors metrics.Counter, logger log.Logger) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		request, err := dec(ctx, r)
+		if err != nil {
+			decodeErrors.With("path", r.URL.Path).Add(1)
+			level.Info(logger).Log("msg", "decode error", "path", r.URL.Path, "err", err)
+		}
+		return request, err
+	}
+}
+
+// maxBodyHandler stops h from reading more than max bytes of a request
+// body. Decoders that hit the limit report ErrTooLong, which is answered
+// with a 413 through encodeError.
+func maxBodyHandler(h http.Handler, max int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, max)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// decodeUppercaseRequest also accepts ?validate=true in place of
+// validate_only in the body.
+func decodeUppercaseRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request uppercaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	v, err := validateParam(r)
+	if err != nil {
+		return nil, err
+	}
+	request.ValidateOnly = request.ValidateOnly || v
+	return request, nil
+}
+
+// validateParam reports whether r asks for validation only with
+// ?validate=true.
+func validateParam(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("validate")
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, ErrBadRequest
+	}
+	return b, nil
+}
+
+func decodeLowercaseRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request lowercaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+// decodeCountRequest reads s from the query string of a GET request, so
+// that /count?s=hello works from a browser, and from the JSON body
+// otherwise.
+func decodeCountRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	v, err := validateParam(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Method == http.MethodGet {
+		return countRequest{S: r.URL.Query().Get("s"), ValidateOnly: v}, nil
+	}
+	var request countRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	request.ValidateOnly = request.ValidateOnly || v
+	return request, nil
+}
+
+func decodeReverseRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request reverseRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+// decodeTrimRequest leaves Cutset empty when the field is omitted, which
+// makes Trim fall back to trimming whitespace.
+func decodeTrimRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request trimRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+// decodeHealthRequest ignores the request body so the health check can be
+// probed with a plain GET.
+func decodeHealthRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return healthRequest{}, nil
+}
+
+// decodeVersionRequest, like decodeHealthRequest, ignores the request body.
+func decodeVersionRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return versionRequest{}, nil
+}
+
+// decodeUppercaseBatchRequest returns a decoder that rejects batches of more
+// than maxBatchSize strings with ErrBatchTooLarge.
+func decodeUppercaseBatchRequest(maxBatchSize int) httptransport.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		var request uppercaseBatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			return nil, decodeError(err)
+		}
+		if len(request.SS) > maxBatchSize {
+			return nil, ErrBatchTooLarge
+		}
+		return request, nil
+	}
+}
+
+func decodeWordCountRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request wordCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeRuneCountRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request runeCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeConcatRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request concatRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+// decodeReplaceRequest defaults N to -1, replacing every occurrence, when
+// the field is omitted.
+func decodeReplaceRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	request := replaceRequest{N: -1}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeContainsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request containsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeSplitRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request splitRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeTitleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request titleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodePadRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request padRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeCapitalizeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request capitalizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeBase64EncodeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request base64EncodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeBase64DecodeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request base64DecodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeHashRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request hashRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeUppercaseExceptRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request uppercaseExceptRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeCharFrequencyRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request charFrequencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeDistanceRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request distanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeRepeatRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request repeatRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeTruncateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request truncateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeIsPalindromeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request isPalindromeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
 	}
+	return request, nil
 }
 
-func makeCountEndpoint(svc StringService) endpoint.Endpoint {
-	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		req := request.(countRequest)
-		v := svc.Count(req.S)
-		return countResponse{v}, nil
+func decodeNormalizeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request normalizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
 	}
+	return request, nil
 }
 
-func decodeUppercaseRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	var request uppercaseRequest
+func decodeSoundexRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request soundexRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		return nil, err
+		return nil, decodeError(err)
 	}
 	return request, nil
 }
 
-func decodeCountRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	var request countRequest
+func decodeCountSubstrRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request countSubstrRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		return nil, err
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeConvertCaseRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request convertCaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeRegexFindRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request regexFindRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeCollapseSpacesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request collapseSpacesRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeDetectScriptRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request detectScriptRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeURLEncodeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request urlEncodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeURLDecodeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request urlDecodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeSlugifyRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request slugifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+func decodeMaskRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request maskRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
 	}
 	return request, nil
 }
 
-func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+func decodeLineCountRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request lineCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, decodeError(err)
+	}
+	return request, nil
+}
+
+// encodeResponse writes the response as JSON, or as plain text when the
+// caller prefers text/plain and the response holds a single scalar value.
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if wantsPlainText(ctx) {
+		if v, ok := plainValue(response); ok {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, err := fmt.Fprintln(w, v)
+			return err
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	return json.NewEncoder(w).Encode(response)
 }
 
+// wantsPlainText reports whether text/plain comes before any JSON media type
+// in the request's Accept header. The Accept header reaches the context via
+// httptransport.PopulateRequestContext.
+func wantsPlainText(ctx context.Context) bool {
+	accept, _ := ctx.Value(httptransport.ContextKeyRequestAccept).(string)
+	for _, mediaRange := range strings.Split(accept, ",") {
+		if i := strings.Index(mediaRange, ";"); i >= 0 {
+			mediaRange = mediaRange[:i]
+		}
+		switch strings.TrimSpace(mediaRange) {
+		case "text/plain":
+			return true
+		case "application/json", "application/*", "*/*":
+			return false
+		}
+	}
+	return false
+}
+
+// plainValue returns the V field of a response if it is a string, number or
+// bool.
+func plainValue(response interface{}) (interface{}, bool) {
+	rv := reflect.ValueOf(response)
+	if rv.Kind() != reflect.Struct {
+		return nil, false
+	}
+	v := rv.FieldByName("V")
+	if !v.IsValid() {
+		return nil, false
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return v.Interface(), true
+	}
+	return nil, false
+}
+
+// encodeError is used as the ServerErrorEncoder for every handler. Errors
+// returned from decoders and endpoints are written as JSON with a status code
+// that reflects the cause.
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	echoRequestID(ctx, w)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(codeFrom(err))
+	json.NewEncoder(w).Encode(newErrorResponse(err))
+}
+
+// newErrorResponse builds the JSON error body for err, including its code
+// if it is a ServiceError and the invalid fields if it is a
+// ValidationError.
+func newErrorResponse(err error) errorResponse {
+	resp := errorResponse{Error: err.Error()}
+	var se *ServiceError
+	if errors.As(err, &se) {
+		resp.Code = se.Code
+	}
+	var ve ValidationError
+	if errors.As(err, &ve) {
+		resp.Fields = ve.Fields
+	}
+	return resp
+}
+
+// statusClientClosedRequest is the non-standard status, from nginx, for a
+// request the caller gave up on before it was answered.
+const statusClientClosedRequest = 499
+
+// codeFrom maps an error to the HTTP status code reported to the caller. A
+// ServiceError carries its own status; other errors not listed here are
+// reported as 500.
+func codeFrom(err error) int {
+	var se *ServiceError
+	switch {
+	case errors.As(err, &se):
+		return se.Status
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// encodeHealthResponse reports an unhealthy service with a 503 so that
+// liveness and readiness probes fail.
+func encodeHealthResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp := response.(healthResponse); resp.Status != "ok" {
+		// Headers set after WriteHeader are ignored, so this can't be left
+		// to encodeResponse.
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	return encodeResponse(ctx, w, response)
+}
+
 type uppercaseRequest struct {
-	S string `json:"s"`
+	S            string `json:"s"`
+	ValidateOnly bool   `json:"validate_only"`
 }
 
 type uppercaseResponse struct {
+	V string `json:"v"`
+}
+
+type lowercaseRequest struct {
+	S string `json:"s"`
+}
+
+type lowercaseResponse struct {
+	V string `json:"v"`
+}
+
+type countRequest struct {
+	S            string `json:"s"`
+	ValidateOnly bool   `json:"validate_only"`
+}
+
+// countResponse keeps v, the byte count, for clients written before bytes
+// and runes were added.
+type countResponse struct {
+	V     int `json:"v"`
+	Bytes int `json:"bytes"`
+	Runes int `json:"runes"`
+}
+
+type reverseRequest struct {
+	S string `json:"s"`
+}
+
+type reverseResponse struct {
+	V string `json:"v"`
+}
+
+type trimRequest struct {
+	S      string `json:"s"`
+	Cutset string `json:"cutset,omitempty"`
+}
+
+type trimResponse struct {
+	V string `json:"v"`
+}
+
+type healthRequest struct{}
+
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+type versionRequest struct{}
+
+type versionResponse struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildTime string `json:"build_time"`
+}
+
+type uppercaseBatchRequest struct {
+	SS []string `json:"ss"`
+}
+
+type uppercaseBatchResponse struct {
+	Results []batchResult `json:"results"`
+}
+
+// batchResult reports the outcome of one item in a batch request. Items
+// fail independently, so errors are carried in the body.
+type batchResult struct {
 	V   string `json:"v"`
 	Err string `json:"err,omitempty"`
 }
 
-type countRequest struct {
+type wordCountRequest struct {
 	S string `json:"s"`
 }
 
-type countResponse struct {
+type wordCountResponse struct {
+	V int `json:"v"`
+}
+
+type runeCountRequest struct {
+	S string `json:"s"`
+}
+
+type runeCountResponse struct {
+	V int `json:"v"`
+}
+
+type errorResponse struct {
+	Error  string            `json:"error"`
+	Code   string            `json:"code,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+type concatRequest struct {
+	SS  []string `json:"ss"`
+	Sep string   `json:"sep"`
+}
+
+type concatResponse struct {
+	V string `json:"v"`
+}
+
+type replaceRequest struct {
+	S   string `json:"s"`
+	Old string `json:"old"`
+	New string `json:"new"`
+	N   int    `json:"n"`
+}
+
+type replaceResponse struct {
+	V string `json:"v"`
+}
+
+type containsRequest struct {
+	S      string `json:"s"`
+	Substr string `json:"substr"`
+}
+
+type containsResponse struct {
+	V bool `json:"v"`
+}
+
+type splitRequest struct {
+	S   string `json:"s"`
+	Sep string `json:"sep"`
+}
+
+type splitResponse struct {
+	Parts []string `json:"parts"`
+}
+
+type titleRequest struct {
+	S string `json:"s"`
+}
+
+type titleResponse struct {
+	V string `json:"v"`
+}
+
+type padRequest struct {
+	S     string `json:"s"`
+	Width int    `json:"width"`
+	Pad   string `json:"pad"`
+	Left  bool   `json:"left"`
+}
+
+type padResponse struct {
+	V string `json:"v"`
+}
+
+type capitalizeRequest struct {
+	S string `json:"s"`
+}
+
+type capitalizeResponse struct {
+	V string `json:"v"`
+}
+
+type base64EncodeRequest struct {
+	S string `json:"s"`
+}
+
+type base64EncodeResponse struct {
+	V string `json:"v"`
+}
+
+type base64DecodeRequest struct {
+	S string `json:"s"`
+}
+
+type base64DecodeResponse struct {
+	V string `json:"v"`
+}
+
+type hashRequest struct {
+	S    string `json:"s"`
+	Algo string `json:"algo"`
+}
+
+type hashResponse struct {
+	V string `json:"v"`
+}
+
+type uppercaseExceptRequest struct {
+	S      string `json:"s"`
+	Except string `json:"except"`
+}
+
+type uppercaseExceptResponse struct {
+	V string `json:"v"`
+}
+
+type charFrequencyRequest struct {
+	S string `json:"s"`
+}
+
+type charFrequencyResponse struct {
+	V map[string]int `json:"v"`
+}
+
+type distanceRequest struct {
+	A string `json:"a"`
+	B string `json:"b"`
+}
+
+type distanceResponse struct {
+	V int `json:"v"`
+}
+
+type repeatRequest struct {
+	S     string `json:"s"`
+	Count int    `json:"count"`
+}
+
+type repeatResponse struct {
+	V string `json:"v"`
+}
+
+type truncateRequest struct {
+	S        string `json:"s"`
+	Max      int    `json:"max"`
+	Ellipsis bool   `json:"ellipsis"`
+}
+
+type truncateResponse struct {
+	V string `json:"v"`
+}
+
+type isPalindromeRequest struct {
+	S     string `json:"s"`
+	Strip bool   `json:"strip"`
+}
+
+type isPalindromeResponse struct {
+	V bool `json:"v"`
+}
+
+type normalizeRequest struct {
+	S    string `json:"s"`
+	Form string `json:"form"`
+}
+
+type normalizeResponse struct {
+	V string `json:"v"`
+}
+
+type soundexRequest struct {
+	S string `json:"s"`
+}
+
+type soundexResponse struct {
+	V string `json:"v"`
+}
+
+type countSubstrRequest struct {
+	S      string `json:"s"`
+	Substr string `json:"substr"`
+}
+
+type countSubstrResponse struct {
+	V int `json:"v"`
+}
+
+type convertCaseRequest struct {
+	S  string `json:"s"`
+	To string `json:"to"`
+}
+
+type convertCaseResponse struct {
+	V string `json:"v"`
+}
+
+type regexFindRequest struct {
+	S       string `json:"s"`
+	Pattern string `json:"pattern"`
+}
+
+type regexFindResponse struct {
+	V []string `json:"v"`
+}
+
+type collapseSpacesRequest struct {
+	S string `json:"s"`
+}
+
+type collapseSpacesResponse struct {
+	V string `json:"v"`
+}
+
+type detectScriptRequest struct {
+	S string `json:"s"`
+}
+
+type detectScriptResponse struct {
+	V string `json:"v"`
+}
+
+type urlEncodeRequest struct {
+	S string `json:"s"`
+}
+
+type urlEncodeResponse struct {
+	V string `json:"v"`
+}
+
+type urlDecodeRequest struct {
+	S string `json:"s"`
+}
+
+type urlDecodeResponse struct {
+	V string `json:"v"`
+}
+
+type slugifyRequest struct {
+	S string `json:"s"`
+}
+
+type slugifyResponse struct {
+	V string `json:"v"`
+}
+
+type maskRequest struct {
+	S       string `json:"s"`
+	Visible int    `json:"visible"`
+	Mask    string `json:"mask"`
+}
+
+type maskResponse struct {
+	V string `json:"v"`
+}
+
+type lineCountRequest struct {
+	S string `json:"s"`
+}
+
+type lineCountResponse struct {
 	V int `json:"v"`
 }
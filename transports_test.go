@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics/discard"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"golang.org/x/time/rate"
+)
+
+func TestUppercaseEndpoint(t *testing.T) {
+	e := makeUppercaseEndpoint(stringService{})
+	resp, err := e(context.Background(), uppercaseRequest{S: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (uppercaseResponse{"HELLO"}); resp != want {
+		t.Errorf("response = %+v, want %+v", resp, want)
+	}
+	if _, err := e(context.Background(), uppercaseRequest{}); !errors.Is(err, ErrEmpty) {
+		t.Errorf("empty s: error = %v, want %v", err, ErrEmpty)
+	}
+}
+
+func TestCountEndpoint(t *testing.T) {
+	e := makeCountEndpoint(stringService{})
+	resp, err := e(context.Background(), countRequest{S: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (countResponse{V: 5, Bytes: 5, Runes: 5}); resp != want {
+		t.Errorf("response = %+v, want %+v", resp, want)
+	}
+	if _, err := e(context.Background(), countRequest{}); !errors.Is(err, ErrEmpty) {
+		t.Errorf("empty s: error = %v, want %v", err, ErrEmpty)
+	}
+}
+
+// unreachableService fails the test if Uppercase or Count is called.
+type unreachableService struct {
+	StringService
+	t *testing.T
+}
+
+func (s unreachableService) Uppercase(context.Context, string) (string, error) {
+	s.t.Error("Uppercase called with a done context")
+	return "", nil
+}
+
+func (s unreachableService) Count(context.Context, string) (Counts, error) {
+	s.t.Error("Count called with a done context")
+	return Counts{}, nil
+}
+
+func TestEndpointsCheckContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	svc := unreachableService{t: t}
+	tests := []struct {
+		name    string
+		e       endpoint.Endpoint
+		dec     httptransport.DecodeRequestFunc
+		request interface{}
+	}{
+		{"uppercase", makeUppercaseEndpoint(svc), decodeUppercaseRequest, uppercaseRequest{S: "hello"}},
+		{"count", makeCountEndpoint(svc), decodeCountRequest, countRequest{S: "hello"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.e(canceled, tt.request); err != context.Canceled {
+				t.Errorf("canceled: error = %v, want %v", err, context.Canceled)
+			}
+			if _, err := tt.e(expired, tt.request); err != context.DeadlineExceeded {
+				t.Errorf("expired: error = %v, want %v", err, context.DeadlineExceeded)
+			}
+
+			// Over HTTP, the context errors become statuses of their own
+			// rather than a 500.
+			for _, c := range []struct {
+				name string
+				ctx  context.Context
+				want int
+			}{
+				{"canceled", canceled, statusClientClosedRequest},
+				{"expired", expired, http.StatusServiceUnavailable},
+			} {
+				ctx := c.ctx
+				srv := httptransport.NewServer(tt.e, tt.dec, encodeResponse,
+					httptransport.ServerErrorEncoder(encodeError),
+					httptransport.ServerBefore(func(context.Context, *http.Request) context.Context { return ctx }),
+				)
+				rec := httptest.NewRecorder()
+				srv.ServeHTTP(rec, httptest.NewRequest("POST", "/"+tt.name, strings.NewReader(`{"s":"hello"}`)))
+				if rec.Code != c.want {
+					t.Errorf("%s over HTTP: status = %d, want %d", c.name, rec.Code, c.want)
+				}
+			}
+		})
+	}
+}
+
+// newTestServer serves /uppercase and /count from svc with the decoders and
+// encoders used by main, but without the middleware chain.
+func newTestServer(svc StringService) *httptest.Server {
+	errorEncoder := httptransport.ServerErrorEncoder(encodeError)
+	mux := http.NewServeMux()
+	mux.Handle("POST /uppercase", httptransport.NewServer(makeUppercaseEndpoint(svc), decodeUppercaseRequest, encodeResponse, errorEncoder))
+	mux.Handle("GET /count", httptransport.NewServer(makeCountEndpoint(svc), decodeCountRequest, encodeResponse, errorEncoder))
+	mux.Handle("POST /count", httptransport.NewServer(makeCountEndpoint(svc), decodeCountRequest, encodeResponse, errorEncoder))
+	return httptest.NewServer(mux)
+}
+
+func TestHTTPTransport(t *testing.T) {
+	srv := newTestServer(stringService{})
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		want   string
+	}{
+		{"uppercase", "POST", "/uppercase", `{"s":"hello"}`, http.StatusOK, `{"v":"HELLO"}`},
+		{"uppercase/empty", "POST", "/uppercase", `{"s":""}`, http.StatusBadRequest, `{"error":"empty string","code":"empty"}`},
+		{"count", "POST", "/count", `{"s":"héllo"}`, http.StatusOK, `{"v":6,"bytes":6,"runes":5}`},
+		{"count/query", "GET", "/count?s=abc", "", http.StatusOK, `{"v":3,"bytes":3,"runes":3}`},
+		{"count/empty", "POST", "/count", `{}`, http.StatusBadRequest, `{"error":"empty string","code":"empty"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			var got, want interface{}
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("body = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeMalformedJSON(t *testing.T) {
+	srv := newTestServer(stringService{})
+	defer srv.Close()
+
+	bodies := []struct {
+		name string
+		body string
+	}{
+		{"truncated", `{"s":"hel`},
+		{"not json", `s=hello`},
+		{"empty", ``},
+		{"wrong type", `{"s":42}`},
+	}
+	for _, path := range []string{"/uppercase", "/count"} {
+		for _, tt := range bodies {
+			t.Run(path+"/"+tt.name, func(t *testing.T) {
+				resp, err := http.Post(srv.URL+path, "application/json", strings.NewReader(tt.body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer resp.Body.Close()
+				if resp.StatusCode != http.StatusBadRequest {
+					t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+				}
+				var got errorResponse
+				if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+					t.Fatal(err)
+				}
+				if want := (errorResponse{Error: "invalid JSON body", Code: "bad_request"}); !reflect.DeepEqual(got, want) {
+					t.Errorf("body = %+v, want %+v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestContentType(t *testing.T) {
+	const want = "application/json; charset=utf-8"
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		write  func(w http.ResponseWriter)
+		status int
+	}{
+		{"response", func(w http.ResponseWriter) { encodeResponse(ctx, w, uppercaseResponse{"HELLO"}) }, http.StatusOK},
+		{"error", func(w http.ResponseWriter) { encodeError(ctx, ErrEmpty, w) }, http.StatusBadRequest},
+		{"healthy", func(w http.ResponseWriter) { encodeHealthResponse(ctx, w, healthResponse{"ok"}) }, http.StatusOK},
+		{"unhealthy", func(w http.ResponseWriter) { encodeHealthResponse(ctx, w, healthResponse{"unavailable"}) }, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.write(rec)
+			// Result reports the headers as they were when the status was
+			// written, which is what a client would see.
+			resp := rec.Result()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := resp.Header.Get("Content-Type"); got != want {
+				t.Errorf("Content-Type = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	const max = 64
+	handler := httptransport.NewServer(
+		makeUppercaseEndpoint(stringService{}),
+		decodeUppercaseRequest,
+		encodeResponse,
+		httptransport.ServerErrorEncoder(encodeError),
+	)
+	srv := httptest.NewServer(maxBodyHandler(handler, max))
+	defer srv.Close()
+
+	// body returns a request body of exactly n bytes.
+	body := func(n int) string {
+		return `{"s":"` + strings.Repeat("a", n-len(`{"s":""}`)) + `"}`
+	}
+	tests := []struct {
+		name   string
+		n      int
+		status int
+	}{
+		{"below", max - 1, http.StatusOK},
+		{"at", max, http.StatusOK},
+		{"above", max + 1, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(srv.URL, "application/json", strings.NewReader(body(tt.n)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status == http.StatusOK {
+				return
+			}
+			var got errorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Code != ErrTooLong.Code {
+				t.Errorf("code = %q, want %q", got.Code, ErrTooLong.Code)
+			}
+		})
+	}
+}
+
+// TestServerLimits checks that a server set up as in main answers requests
+// over its header and body limits with a status code rather than dropping
+// the connection.
+func TestServerLimits(t *testing.T) {
+	const maxHeaderBytes = 1 << 10
+	const maxBodyBytes = 1 << 10
+	mux := http.NewServeMux()
+	mux.Handle("POST /uppercase", httptransport.NewServer(
+		makeUppercaseEndpoint(stringService{}),
+		decodeUppercaseRequest,
+		encodeResponse,
+		httptransport.ServerErrorEncoder(encodeError),
+	))
+	srv := httptest.NewUnstartedServer(maxBodyHandler(mux, maxBodyBytes))
+	srv.Config.MaxHeaderBytes = maxHeaderBytes
+	srv.Start()
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		header string
+		body   string
+		status int
+	}{
+		{"within limits", "x", `{"s":"hello"}`, http.StatusOK},
+		{"large body", "x", `{"s":"` + strings.Repeat("a", maxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge},
+		// The server allows some slack over MaxHeaderBytes, so the header
+		// has to be well over it to be rejected.
+		{"large header", strings.Repeat("x", 8*maxHeaderBytes), `{"s":"hello"}`, http.StatusRequestHeaderFieldsTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", srv.URL+"/uppercase", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-Padding", tt.header)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+// BenchmarkHTTPUppercase measures a full round trip to /uppercase through
+// the service and endpoint middlewares that main puts in front of it, so
+// that the cost of adding a middleware shows up here. The rate limit is
+// lifted and the input varies so that neither the limiter nor the cache
+// cuts the chain short.
+func BenchmarkHTTPUppercase(b *testing.B) {
+	cfg := defaultConfig()
+	logger := log.NewNopLogger()
+	caching, err := newCachingMiddleware(cfg.CacheSize, cfg.CacheMaxInput, discard.NewCounter())
+	if err != nil {
+		b.Fatal(err)
+	}
+	var draining atomic.Bool
+	svc := decorate(stringService{},
+		newValidatingMiddleware(cfg.MaxInputLength, cfg.MaxOutputLength),
+		caching,
+		newLoggingMiddleware(logger),
+		newRateLimitingMiddleware(float64(rate.Inf), 0, nil),
+		newInstrumentingMiddleware(discard.NewCounter(), discard.NewHistogram(), discard.NewHistogram(), discard.NewHistogram(), discard.NewHistogram(), discard.NewCounter()),
+		newDrainingMiddleware(&draining),
+	)
+	mw := endpoint.Chain(
+		recoveryMiddleware(logger, discard.NewCounter(), "uppercase"),
+		requestValidationMiddleware,
+		concurrencyLimitingMiddleware(cfg.MaxConcurrent, discard.NewGauge()),
+		retryMiddleware(retryMax, retryBackoff),
+		circuitBreakingMiddleware("uppercase", breakerTimeout, breakerMaxRequests),
+	)
+	handler := httptransport.NewServer(
+		mw(makeUppercaseEndpoint(svc)),
+		decodeUppercaseRequest,
+		encodeResponse,
+		httptransport.ServerErrorEncoder(encodeError),
+	)
+	srv := httptest.NewServer(maxBodyHandler(handler, int64(cfg.MaxBodyBytes)))
+	defer srv.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		body := fmt.Sprintf(`{"s":"the quick brown fox %d"}`, i)
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			b.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			b.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+	}
+}
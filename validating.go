@@ -0,0 +1,393 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrTooLong is returned when an input exceeds the maximum accepted length.
+var ErrTooLong = &ServiceError{Code: "too_long", Status: http.StatusRequestEntityTooLarge, Message: "input too long"}
+
+// ErrOutputTooLong is returned when a call would produce a result longer
+// than the maximum allowed.
+var ErrOutputTooLong = &ServiceError{Code: "output_too_long", Status: http.StatusRequestEntityTooLarge, Message: "output too long"}
+
+// validatingMiddleware rejects calls whose input is longer than maxLength
+// bytes, or whose output is known up front to be longer than maxOutputLength
+// bytes, before any work is done on them.
+type validatingMiddleware struct {
+	maxLength       int
+	maxOutputLength int
+	next            StringService
+}
+
+// newValidatingMiddleware returns a Middleware that rejects inputs longer
+// than maxLength bytes with ErrTooLong, and calls that would produce more
+// than maxOutputLength bytes with ErrOutputTooLong.
+func newValidatingMiddleware(maxLength, maxOutputLength int) Middleware {
+	return func(next StringService) StringService {
+		return validatingMiddleware{maxLength, maxOutputLength, next}
+	}
+}
+
+func (mw validatingMiddleware) check(s string) error {
+	if len(s) > mw.maxLength {
+		return ErrTooLong
+	}
+	return nil
+}
+
+// withValidateOnly marks ctx so that calls made with it stop once their input
+// has been validated. Only Uppercase and Count honor it.
+func withValidateOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, validateOnlyContextKey, true)
+}
+
+func validateOnly(ctx context.Context) bool {
+	v, _ := ctx.Value(validateOnlyContextKey).(bool)
+	return v
+}
+
+// checkOnly reports the error, if any, that the service would return for s
+// before doing any work on it. Calls through the endpoints never get
+// ErrEmpty here, because requestValidationMiddleware rejects an empty s
+// with a 422 first; it remains for callers of the service itself.
+func (mw validatingMiddleware) checkOnly(s string) error {
+	if s == "" {
+		return ErrEmpty
+	}
+	return mw.check(s)
+}
+
+func (mw validatingMiddleware) Uppercase(ctx context.Context, s string) (string, error) {
+	if validateOnly(ctx) {
+		return "", mw.checkOnly(s)
+	}
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Uppercase(ctx, s)
+}
+
+func (mw validatingMiddleware) Lowercase(ctx context.Context, s string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Lowercase(ctx, s)
+}
+
+func (mw validatingMiddleware) Count(ctx context.Context, s string) (Counts, error) {
+	if validateOnly(ctx) {
+		return Counts{}, mw.checkOnly(s)
+	}
+	if err := mw.check(s); err != nil {
+		return Counts{}, err
+	}
+	return mw.next.Count(ctx, s)
+}
+
+func (mw validatingMiddleware) Reverse(ctx context.Context, s string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Reverse(ctx, s)
+}
+
+func (mw validatingMiddleware) Trim(ctx context.Context, s string, cutset string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Trim(ctx, s, cutset)
+}
+
+func (mw validatingMiddleware) Health(ctx context.Context) (bool, error) {
+	return mw.next.Health(ctx)
+}
+
+// UppercaseBatch rejects only the items that are too long and passes the
+// rest on.
+func (mw validatingMiddleware) UppercaseBatch(ctx context.Context, ss []string) ([]string, []error) {
+	vs := make([]string, len(ss))
+	errs := make([]error, len(ss))
+	var valid []string
+	var index []int
+	for i, s := range ss {
+		if errs[i] = mw.check(s); errs[i] == nil {
+			valid = append(valid, s)
+			index = append(index, i)
+		}
+	}
+	if len(valid) == 0 {
+		return vs, errs
+	}
+	validVs, validErrs := mw.next.UppercaseBatch(ctx, valid)
+	for j, i := range index {
+		vs[i], errs[i] = validVs[j], validErrs[j]
+	}
+	return vs, errs
+}
+
+func (mw validatingMiddleware) WordCount(ctx context.Context, s string) (int, error) {
+	if err := mw.check(s); err != nil {
+		return 0, err
+	}
+	return mw.next.WordCount(ctx, s)
+}
+
+func (mw validatingMiddleware) RuneCount(ctx context.Context, s string) (int, error) {
+	if err := mw.check(s); err != nil {
+		return 0, err
+	}
+	return mw.next.RuneCount(ctx, s)
+}
+
+// Concat limits the length of the joined result rather than of each part.
+func (mw validatingMiddleware) Concat(ctx context.Context, ss []string, sep string) (string, error) {
+	n := len(sep) * (len(ss) - 1)
+	for _, s := range ss {
+		n += len(s)
+	}
+	if len(ss) > 0 && n > mw.maxLength {
+		return "", ErrTooLong
+	}
+	return mw.next.Concat(ctx, ss, sep)
+}
+
+func (mw validatingMiddleware) Replace(ctx context.Context, s, old, new string, n int) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	// Only a longer replacement can make the output longer than s. As in
+	// Repeat, dividing rather than multiplying can't overflow.
+	if growth := len(new) - len(old); growth > 0 {
+		k := strings.Count(s, old)
+		if n >= 0 {
+			k = min(k, n)
+		}
+		if k > (mw.maxOutputLength-len(s))/growth {
+			return "", ErrOutputTooLong
+		}
+	}
+	return mw.next.Replace(ctx, s, old, new, n)
+}
+
+func (mw validatingMiddleware) Contains(ctx context.Context, s, substr string) (bool, error) {
+	if err := mw.check(s); err != nil {
+		return false, err
+	}
+	return mw.next.Contains(ctx, s, substr)
+}
+
+func (mw validatingMiddleware) Split(ctx context.Context, s, sep string) ([]string, error) {
+	if err := mw.check(s); err != nil {
+		return nil, err
+	}
+	return mw.next.Split(ctx, s, sep)
+}
+
+func (mw validatingMiddleware) Title(ctx context.Context, s string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Title(ctx, s)
+}
+
+func (mw validatingMiddleware) Version(ctx context.Context) (BuildInfo, error) {
+	return mw.next.Version(ctx)
+}
+
+// Pad works out how many bytes of padding it would add without building
+// them. As in Repeat, dividing rather than multiplying can't overflow.
+func (mw validatingMiddleware) Pad(ctx context.Context, s string, width int, pad string, left bool) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	n := width - utf8.RuneCountInString(s)
+	if k := utf8.RuneCountInString(pad); n > 0 && k > 0 {
+		// The padding is n/k whole copies of pad followed by its first
+		// n%k runes, which take up rest bytes.
+		rest, r := 0, n%k
+		for i := range pad {
+			if r == 0 {
+				rest = i
+				break
+			}
+			r--
+		}
+		room := mw.maxOutputLength - len(s) - rest
+		if room < 0 || n/k > room/len(pad) {
+			return "", ErrOutputTooLong
+		}
+	}
+	return mw.next.Pad(ctx, s, width, pad, left)
+}
+
+func (mw validatingMiddleware) Capitalize(ctx context.Context, s string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Capitalize(ctx, s)
+}
+
+func (mw validatingMiddleware) Base64Encode(ctx context.Context, s string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Base64Encode(ctx, s)
+}
+
+func (mw validatingMiddleware) Base64Decode(ctx context.Context, s string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Base64Decode(ctx, s)
+}
+
+func (mw validatingMiddleware) Hash(ctx context.Context, s, algo string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Hash(ctx, s, algo)
+}
+
+func (mw validatingMiddleware) UppercaseExcept(ctx context.Context, s, except string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.UppercaseExcept(ctx, s, except)
+}
+
+func (mw validatingMiddleware) CharFrequency(ctx context.Context, s string) (map[string]int, error) {
+	if err := mw.check(s); err != nil {
+		return nil, err
+	}
+	return mw.next.CharFrequency(ctx, s)
+}
+
+func (mw validatingMiddleware) Distance(ctx context.Context, a, b string) (int, error) {
+	if err := mw.check(a); err != nil {
+		return 0, err
+	}
+	if err := mw.check(b); err != nil {
+		return 0, err
+	}
+	return mw.next.Distance(ctx, a, b)
+}
+
+func (mw validatingMiddleware) Repeat(ctx context.Context, s string, count int) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	// Dividing rather than multiplying can't overflow.
+	if len(s) > 0 && count > mw.maxOutputLength/len(s) {
+		return "", ErrOutputTooLong
+	}
+	return mw.next.Repeat(ctx, s, count)
+}
+
+func (mw validatingMiddleware) Truncate(ctx context.Context, s string, max int, ellipsis bool) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Truncate(ctx, s, max, ellipsis)
+}
+
+func (mw validatingMiddleware) IsPalindrome(ctx context.Context, s string, strip bool) (bool, error) {
+	if err := mw.check(s); err != nil {
+		return false, err
+	}
+	return mw.next.IsPalindrome(ctx, s, strip)
+}
+
+func (mw validatingMiddleware) Normalize(ctx context.Context, s, form string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Normalize(ctx, s, form)
+}
+
+func (mw validatingMiddleware) Soundex(ctx context.Context, s string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Soundex(ctx, s)
+}
+
+func (mw validatingMiddleware) CountSubstr(ctx context.Context, s, substr string) (int, error) {
+	if err := mw.check(s); err != nil {
+		return 0, err
+	}
+	return mw.next.CountSubstr(ctx, s, substr)
+}
+
+func (mw validatingMiddleware) ConvertCase(ctx context.Context, s, to string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.ConvertCase(ctx, s, to)
+}
+
+func (mw validatingMiddleware) RegexFind(ctx context.Context, s, pattern string) ([]string, error) {
+	if err := mw.check(s); err != nil {
+		return nil, err
+	}
+	return mw.next.RegexFind(ctx, s, pattern)
+}
+
+func (mw validatingMiddleware) CollapseSpaces(ctx context.Context, s string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.CollapseSpaces(ctx, s)
+}
+
+func (mw validatingMiddleware) DetectScript(ctx context.Context, s string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.DetectScript(ctx, s)
+}
+
+func (mw validatingMiddleware) URLEncode(ctx context.Context, s string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.URLEncode(ctx, s)
+}
+
+func (mw validatingMiddleware) URLDecode(ctx context.Context, s string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.URLDecode(ctx, s)
+}
+
+func (mw validatingMiddleware) Slugify(ctx context.Context, s string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Slugify(ctx, s)
+}
+
+func (mw validatingMiddleware) Mask(ctx context.Context, s string, visible int, mask string) (string, error) {
+	if err := mw.check(s); err != nil {
+		return "", err
+	}
+	return mw.next.Mask(ctx, s, visible, mask)
+}
+
+func (mw validatingMiddleware) LineCount(ctx context.Context, s string) (int, error) {
+	if err := mw.check(s); err != nil {
+		return 0, err
+	}
+	return mw.next.LineCount(ctx, s)
+}
+
+// UppercaseStream isn't checked here: its input isn't known up front, so
+// the transport limits how much of it is read.
+func (mw validatingMiddleware) UppercaseStream(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	return mw.next.UppercaseStream(ctx, dst, src)
+}
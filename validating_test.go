@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidatingMaxLength(t *testing.T) {
+	const max = 16
+	svc := newValidatingMiddleware(max, 1<<20)(stringService{})
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		n    int
+		err  error
+	}{
+		{"below", max - 1, nil},
+		{"at", max, nil},
+		{"above", max + 1, ErrTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := strings.Repeat("a", tt.n)
+			if _, err := svc.Uppercase(ctx, s); !errors.Is(err, tt.err) {
+				t.Errorf("Uppercase(%d bytes) error = %v, want %v", tt.n, err, tt.err)
+			}
+			if _, err := svc.Count(ctx, s); !errors.Is(err, tt.err) {
+				t.Errorf("Count(%d bytes) error = %v, want %v", tt.n, err, tt.err)
+			}
+			// The limit is in bytes, so a multibyte rune can push an input
+			// over it.
+			s = strings.Repeat("a", tt.n-2) + "é"
+			if _, err := svc.Uppercase(ctx, s); !errors.Is(err, tt.err) {
+				t.Errorf("Uppercase(%d bytes, multibyte) error = %v, want %v", tt.n, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidatingPadOutputLength(t *testing.T) {
+	const max = 16
+	svc := newValidatingMiddleware(max, max)(stringService{})
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		width int
+		pad   string
+		want  int // length of the result in bytes
+		err   error
+	}{
+		{"below", 8, "é", 14, nil},
+		{"at", 9, "é", 16, nil},
+		{"above", 10, "é", 0, ErrOutputTooLong},
+		// "éx" is 3 bytes, so 9 runes of padding are 4 copies and an "é".
+		{"partial pad at", 11, "éx", 16, nil},
+		// "xé" is 3 bytes, so 10 runes of padding are 5 copies.
+		{"partial pad above", 12, "xé", 0, ErrOutputTooLong},
+		{"huge width", math.MaxInt, "é", 0, ErrOutputTooLong},
+		{"wide enough", 1, "é", 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.Pad(ctx, "ab", tt.width, tt.pad, false)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Pad(width %d, pad %q) error = %v, want %v", tt.width, tt.pad, err, tt.err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("Pad(width %d, pad %q) = %d bytes, want %d", tt.width, tt.pad, len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestErrTooLongStatus(t *testing.T) {
+	if got := codeFrom(ErrTooLong); got != http.StatusRequestEntityTooLarge {
+		t.Errorf("codeFrom(ErrTooLong) = %d, want %d", got, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+// Build information, set at link time with e.g.
+//
+//	go build -ldflags "-X main.version=1.2.0 -X main.gitCommit=$(git rev-parse HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "dev"
+	gitCommit = "unknown"
+	buildTime = "unknown"
+)
+
+// BuildInfo describes the running build.
+type BuildInfo struct {
+	Version   string
+	GitCommit string
+	BuildTime string
+}